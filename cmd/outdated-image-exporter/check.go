@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+var checkFlags = flag.NewFlagSet("check", flag.ExitOnError)
+var checkPinMode = checkFlags.String("pin-mode", "", "Pin mode to apply: [none, major, minor]. Overrides the pin-mode annotation.")
+var checkConstraint = checkFlags.String("constraint", "", "Version constraint to apply, e.g. \"< 2.0\". Overrides the constraint annotation.")
+var checkTimeout = checkFlags.Duration("timeout", 30*time.Second, "Timeout for listing the tags of the image")
+var checkAnnotations = annotationFlag{}
+
+func init() {
+	checkFlags.Var(checkAnnotations, "annotation", "Annotation as key=value, applied like a pod annotation. Can be given multiple times.")
+
+	checkFlags.Usage = func() {
+		_, _ = fmt.Fprintf(checkFlags.Output(), "Usage: %s check [flags] <image>\n\nShows how a single image compares to the tags in its registry.\n\n", os.Args[0])
+		checkFlags.PrintDefaults()
+	}
+
+	shareFlags(checkFlags, "config", "registry-credentials", "log-level")
+}
+
+// parseInterspersed parses flags before and after the positional arguments, like check <image> -pin-mode minor, and
+// returns the positional arguments. Arguments after -- are never parsed as flags.
+func parseInterspersed(flagSet *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		err := flagSet.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		rest := flagSet.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// runCheck lists the tags of a single image and prints the candidates for an update and why the other tags were
+// filtered out.
+func runCheck(args []string) error {
+	positional, err := parseInterspersed(checkFlags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		checkFlags.Usage()
+
+		return errors.New("expected exactly one image reference")
+	}
+
+	image := positional[0]
+
+	cfg, err := loadConfig(checkFlags)
+	if err != nil {
+		return err
+	}
+
+	evaluationConfig, err := newEvaluationConfig(cfg, nil)
+	if err != nil {
+		return err
+	}
+
+	policy, err := version.PolicyFromAnnotations(checkAnnotations, evaluationConfig.DefaultPolicy)
+	if err != nil {
+		return err
+	}
+
+	if *checkPinMode != "" {
+		policy.PinMode, err = version.ParsePinMode(*checkPinMode)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *checkConstraint != "" {
+		policy.Constraint, err = version.ParseConstraint(*checkConstraint)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger, err := newLogger(cfg.Output)
+	if err != nil {
+		return err
+	}
+
+	tagLister, err := newTagLister(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	versionChecker, err := version.NewChecker()
+	if err != nil {
+		return err
+	}
+
+	currentVersion, err := tagLister.GetTagOfImage(image)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *checkTimeout)
+	defer cancel()
+
+	imageTags, err := tagLister.ListTags(ctx, image, &tags.DockerConfigKeychain{})
+	if err != nil {
+		return err
+	}
+
+	evaluation, err := versionChecker.Evaluate(currentVersion, imageTags, policy)
+	if err != nil {
+		return fmt.Errorf("current tag %q can't be compared: %w", currentVersion, err)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintf(writer, "Image:\t%s\n", image)
+	_, _ = fmt.Fprintf(writer, "Current:\t%s\n", evaluation.Current)
+	_, _ = fmt.Fprintf(writer, "Pin mode:\t%s\n", policy.PinMode)
+	_, _ = fmt.Fprintf(writer, "Constraint:\t%s\n", orNone(policy.Constraint.String()))
+	_, _ = fmt.Fprintf(writer, "Tags:\t%d\n", len(imageTags))
+	_, _ = fmt.Fprintf(writer, "Difference:\tmajor=%d minor=%d patch=%d\n", evaluation.Major, evaluation.Minor, evaluation.Patch)
+	_, _ = fmt.Fprintln(writer)
+	_, _ = fmt.Fprintln(writer, "Latest candidates:")
+	_, _ = fmt.Fprintf(writer, "  major\t%s\n", orNone(evaluation.LatestMajor))
+	_, _ = fmt.Fprintf(writer, "  minor\t%s\n", orNone(evaluation.LatestMinor))
+	_, _ = fmt.Fprintf(writer, "  patch\t%s\n", orNone(evaluation.LatestPatch))
+	_, _ = fmt.Fprintln(writer)
+	_, _ = fmt.Fprintf(writer, "Filtered tags (%d):\n", len(evaluation.Filtered))
+
+	for _, filtered := range evaluation.Filtered {
+		_, _ = fmt.Fprintf(writer, "  %s\t%s\n", filtered.Tag, filtered.Reason)
+	}
+
+	return writer.Flush()
+}
+
+func orNone(tag string) string {
+	if tag == "" {
+		return "-"
+	}
+
+	return tag
+}
+
+// annotationFlag collects repeated key=value flags.
+type annotationFlag map[string]string
+
+func (a annotationFlag) String() string {
+	pairs := make([]string, 0, len(a))
+	for key, value := range a {
+		pairs = append(pairs, key+"="+value)
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (a annotationFlag) Set(value string) error {
+	key, annotationValue, found := strings.Cut(value, "=")
+	if !found {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+
+	a[key] = annotationValue
+
+	return nil
+}
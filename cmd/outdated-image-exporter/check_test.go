@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	for _, test := range []struct {
+		args       []string
+		positional []string
+		pinMode    string
+	}{
+		{[]string{"-pin-mode", "minor", "ghcr.io/foo/bar:1.4.2"}, []string{"ghcr.io/foo/bar:1.4.2"}, "minor"},
+		{[]string{"ghcr.io/foo/bar:1.4.2", "--pin-mode", "minor"}, []string{"ghcr.io/foo/bar:1.4.2"}, "minor"},
+		{[]string{"nginx", "-pin-mode=major", "redis"}, []string{"nginx", "redis"}, "major"},
+		{[]string{"--", "-nginx", "-pin-mode", "minor"}, []string{"-nginx", "-pin-mode", "minor"}, ""},
+	} {
+		flagSet := flag.NewFlagSet("check", flag.ContinueOnError)
+		pinMode := flagSet.String("pin-mode", "", "")
+
+		positional, err := parseInterspersed(flagSet, test.args)
+		require.NoError(t, err, test.args)
+		require.Equal(t, test.positional, positional, test.args)
+		require.Equal(t, test.pinMode, *pinMode, test.args)
+	}
+}
+
+func TestParseInterspersed_UnknownFlag(t *testing.T) {
+	flagSet := flag.NewFlagSet("check", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+
+	_, err := parseInterspersed(flagSet, []string{"nginx", "-pin"})
+	require.Error(t, err)
+}
+
+func TestParseInterspersed_CheckFlags(t *testing.T) {
+	t.Cleanup(func() {
+		*checkPinMode = ""
+		*checkConstraint = ""
+	})
+
+	positional, err := parseInterspersed(checkFlags, []string{"ghcr.io/foo/bar:1.4.2", "--pin-mode", "minor", "-constraint", "< 2.0"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"ghcr.io/foo/bar:1.4.2"}, positional)
+	require.Equal(t, "minor", *checkPinMode)
+	require.Equal(t, "< 2.0", *checkConstraint)
+}
+
+func TestParseInterspersed_ScanFlags(t *testing.T) {
+	t.Cleanup(func() {
+		*scanFormat = "table"
+		*scanFailOn = ""
+	})
+
+	positional, err := parseInterspersed(scanFlags, []string{"deploy/", "-format", "json", "charts/", "--fail-on", "minor"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"deploy/", "charts/"}, positional)
+	require.Equal(t, "json", *scanFormat)
+	require.Equal(t, "minor", *scanFailOn)
+}
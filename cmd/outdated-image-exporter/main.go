@@ -2,35 +2,95 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"path"
+	"reflect"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/util/homedir"
 
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/compose"
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/docker"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/dockerfile"
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/manifest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/config"
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/events"
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/exporter"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/imagereport"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/metrics"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/notify"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/policy"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/state"
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/telemetry"
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
 )
 
+var configPath = flag.String("config", "", "Path to a YAML or TOML configuration file. Flags given explicitly override its settings.")
 var inClusterConfig = flag.Bool("in-cluster", true, "Controls if the in-cluster connection configuration method should be used.")
 var imageCheckInterval = flag.Duration("image-check-interval", time.Hour, "How often to check for new image versions. Configuring this to a lower interval will eat up your registry request quota faster.")
 var registryCredentialsPath = flag.String("registry-credentials", path.Join(homedir.HomeDir(), ".docker", "config.json"), "Path to a file containing registry credentials. This is the same format as K8s imagePullSecret contents")
 var listenAddr = flag.String("listen-addr", ":8080", "The address to listen on for metrics requests")
-var containerProvider = flag.String("container", "kubernetes", "Container technology used: [kubernetes, docker]")
+var containerProvider = flag.String("container", "kubernetes", "Comma separated list of container sources: [kubernetes, docker, manifest, dockerfile, compose]")
 var logLevel = flag.String("log-level", "info", "Log level: [debug, info, warning, error]")
+var paths = flag.String("paths", "", "Comma separated list of files or directories to scan with the manifest, dockerfile and compose providers. Use - to read manifests from stdin.")
+var dockerSwarmServices = flag.Bool("docker-swarm-services", false, "List Swarm services through the Docker API, including services without tasks on this node. Requires a Swarm manager.")
+
+// loggerLevel is shared by every logger, so a reload can change the level of running components
+var loggerLevel = new(slog.LevelVar)
+
+// errOutdatedImages signals that a one-shot command found images above the configured threshold
+var errOutdatedImages = errors.New("outdated images found")
+
+// errCheckFailed signals that a one-shot command with -fail-on-error could not check an image
+var errCheckFailed = errors.New("images could not be checked")
 
 func main() {
-	if err := run(); err != nil {
+	var err error
+
+	subcommand := ""
+	if len(os.Args) > 1 {
+		subcommand = os.Args[1]
+	}
+
+	switch subcommand {
+	case "scan":
+		err = runScan(os.Args[2:])
+	case "check":
+		err = runCheck(os.Args[2:])
+	case "update":
+		err = runUpdate(os.Args[2:])
+	case "webhook":
+		err = runWebhook(os.Args[2:])
+	case "push":
+		err = runPush(os.Args[2:])
+	default:
+		err = run()
+	}
+
+	if errors.Is(err, errOutdatedImages) || errors.Is(err, errCheckFailed) {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+
+		os.Exit(2)
+	}
+
+	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "fatal: %v", err)
 
 		os.Exit(1)
@@ -40,58 +100,60 @@ func main() {
 func run() error {
 	flag.Parse()
 
-	var level slog.Level
-	err := level.UnmarshalText([]byte(*logLevel))
+	cfg, err := loadConfig(flag.CommandLine)
 	if err != nil {
 		return err
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
-		AddSource: true,
-		Level:     level,
-	}))
+	logger, err := newLogger(cfg.Output)
+	if err != nil {
+		return err
+	}
 
-	var client evaluation.ContainerClient
+	err = metrics.Register(prometheus.DefaultRegisterer)
+	if err != nil {
+		return err
+	}
 
-	switch *containerProvider {
-	case "kubernetes":
-		k8sClient, err := k8s.NewContainerClient(k8s.ConnectionConfig{
-			InClusterConfig:        *inClusterConfig,
-			InformerResyncInterval: 5 * time.Minute,
-			ImageCheckInterval:     *imageCheckInterval,
-		}, logger)
-		if err != nil {
-			return err
-		}
+	tagLister, err := newTagLister(cfg, logger)
+	if err != nil {
+		return err
+	}
 
-		client = k8sClient
-	case "docker":
-		dockerClient, err := docker.NewDockerClient(logger)
-		if err != nil {
-			return err
-		}
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-		client = dockerClient
-	default:
-		return fmt.Errorf("unsupported provider %q", *containerProvider)
+	policies, err := newPolicyStore(runCtx, cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	evaluator, err := newEvaluator(cfg, tagLister, policies, false, logger)
+	if err != nil {
+		return err
 	}
 
-	authConfig, err := tags.ReadRegistryCredentialsFromFile(*registryCredentialsPath)
+	err = startReportWriter(runCtx, cfg, evaluator, logger)
 	if err != nil {
-		logger.Warn("no registry auth provided. continuing without registry auth", "path", *registryCredentialsPath, "error", err)
+		return err
 	}
 
-	tagLister, err := tags.NewTagLister(authConfig)
+	err = startEventRecorder(runCtx, cfg, evaluator, logger)
 	if err != nil {
 		return err
 	}
 
-	versionChecker, err := version.NewChecker()
+	err = startNotifier(runCtx, cfg, evaluator, logger)
 	if err != nil {
 		return err
 	}
 
-	evaluator, err := evaluation.NewEvaluator(tagLister, versionChecker, client, logger)
+	stateSaved, err := restoreState(runCtx, cfg, evaluator, logger)
+	if err != nil {
+		return err
+	}
+
+	shutdownTelemetry, err := startTelemetry(runCtx, cfg, evaluator)
 	if err != nil {
 		return err
 	}
@@ -103,34 +165,665 @@ func run() error {
 		return err
 	}
 
-	signals := make(chan os.Signal)
-	signal.Notify(signals, syscall.SIGTERM)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGHUP)
 
-	runCtx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Run only ends early if the sources stopped watching, the process then exits non-zero so it gets restarted
+	runErr := make(chan error, 1)
 
 	go func() {
-		err = evaluator.Run(runCtx)
-		if err != nil {
-			logger.Error("failed to run evaluator", "error", err)
-
-			os.Exit(1)
-		}
+		runErr <- evaluator.Run(runCtx)
 	}()
 
-	shutdownFunc, err := exporter.RunServer(*listenAddr)
+	serverConfig, err := newServerConfig(cfg, logger)
 	if err != nil {
 		return err
 	}
 
-	<-signals
+	shutdownFunc, err := exporter.RunServer(serverConfig, evaluator)
+	if err != nil {
+		return err
+	}
+
+	var evaluatorErr error
+
+loop:
+	for {
+		select {
+		case sig := <-signals:
+			if sig != syscall.SIGHUP {
+				break loop
+			}
+
+			cfg = reloadConfig(cfg, evaluator, tagLister, policies, logger)
+		case evaluatorErr = <-runErr:
+			logger.Error("failed to run evaluator", "error", evaluatorErr)
+
+			break loop
+		}
+	}
 
 	cancel()
 
+	<-stateSaved
+
 	err = shutdownFunc()
 	if err != nil {
 		return err
 	}
 
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	err = shutdownTelemetry(shutdownCtx)
+	if err != nil {
+		return err
+	}
+
+	return evaluatorErr
+}
+
+// loadConfig reads the configuration file and applies the flags that were given explicitly on top of it.
+func loadConfig(flagSet *flag.FlagSet) (config.Config, error) {
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	flagSet.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "in-cluster":
+			cfg.Sources.Kubernetes.InCluster = *inClusterConfig
+		case "image-check-interval":
+			cfg.Sources.ImageCheckInterval.Duration = *imageCheckInterval
+		case "registry-credentials":
+			cfg.Auth.CredentialsFile = *registryCredentialsPath
+		case "listen-addr":
+			cfg.Output.ListenAddr = *listenAddr
+		case "container":
+			cfg.Sources.Providers = splitList(*containerProvider)
+		case "log-level":
+			cfg.Output.LogLevel = *logLevel
+		case "paths":
+			cfg.Sources.Paths = splitList(*paths)
+		case "docker-swarm-services":
+			cfg.Sources.Docker.SwarmServices = *dockerSwarmServices
+		}
+	})
+
+	err = cfg.Validate()
+	if err != nil {
+		return config.Config{}, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return cfg, nil
+}
+
+// reloadConfig applies a changed configuration file to the running exporter. Policies, timeouts, registry settings
+// and the log level take effect immediately, other changes need a restart. An invalid file keeps the current
+// configuration.
+func reloadConfig(
+	current config.Config,
+	evaluator *evaluation.Evaluator,
+	tagLister *tags.TagLister,
+	policies *policy.Store,
+	logger *slog.Logger,
+) config.Config {
+	logger.Info("reloading configuration", "path", *configPath)
+
+	cfg, err := loadConfig(flag.CommandLine)
+	if err != nil {
+		logger.Error("failed to reload configuration, keeping the current one", "error", err)
+
+		return current
+	}
+
+	evaluationConfig, err := newEvaluationConfig(cfg, policies)
+	if err != nil {
+		logger.Error("failed to reload configuration, keeping the current one", "error", err)
+
+		return current
+	}
+
+	if !reflect.DeepEqual(cfg.Sources, current.Sources) ||
+		cfg.Evaluator.Workers != current.Evaluator.Workers ||
+		cfg.Output.ListenAddr != current.Output.ListenAddr ||
+		cfg.Output.TLS != current.Output.TLS ||
+		cfg.Output.Access != current.Output.Access ||
+		cfg.Output.LogFormat != current.Output.LogFormat ||
+		cfg.Output.Reports != current.Output.Reports ||
+		cfg.Output.Events != current.Output.Events ||
+		!slices.Equal(cfg.Output.MetricLabels, current.Output.MetricLabels) ||
+		!reflect.DeepEqual(cfg.Notifications, current.Notifications) ||
+		cfg.State != current.State ||
+		!reflect.DeepEqual(cfg.Telemetry, current.Telemetry) {
+		logger.Warn("changes to sources, evaluator workers, listen address, TLS files, endpoint access, log format, reports, events, metric labels, notifications, state or telemetry need a restart")
+	}
+
+	_ = loggerLevel.UnmarshalText([]byte(cfg.Output.LogLevel))
+
+	evaluator.SetConfig(evaluationConfig)
+	tagLister.SetKeychain(newKeychain(cfg.Auth, logger))
+	tagLister.SetOptions(newTagListerOptions(cfg))
+
+	logger.Info("configuration reloaded")
+
+	return cfg
+}
+
+func splitList(list string) []string {
+	var result []string
+
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func newLogger(output config.Output) (*slog.Logger, error) {
+	err := loggerLevel.UnmarshalText([]byte(output.LogLevel))
+	if err != nil {
+		return nil, err
+	}
+
+	options := &slog.HandlerOptions{
+		AddSource: true,
+		Level:     loggerLevel,
+	}
+
+	if output.LogFormat == "text" {
+		return slog.New(slog.NewTextHandler(os.Stderr, options)), nil
+	}
+
+	return slog.New(slog.NewJSONHandler(os.Stderr, options)), nil
+}
+
+func newEvaluationConfig(cfg config.Config, policies *policy.Store) (evaluation.Config, error) {
+	pinMode, err := version.ParsePinMode(cfg.Policy.PinMode)
+	if err != nil {
+		return evaluation.Config{}, err
+	}
+
+	constraint, err := version.ParseConstraint(cfg.Policy.Constraint)
+	if err != nil {
+		return evaluation.Config{}, err
+	}
+
+	return evaluation.Config{
+		DefaultPolicy: version.Policy{
+			PinMode:    pinMode,
+			Constraint: constraint,
+		},
+		Workers:          cfg.Evaluator.Workers,
+		Timeout:          cfg.Evaluator.Timeout.Duration,
+		Policies:         policies,
+		CacheTTL:         cfg.Sources.ImageCheckInterval.Duration,
+		Labels:           cfg.Output.MetricLabels,
+		LastTagsFallback: cfg.State.LastTagsFallback,
+	}, nil
+}
+
+// startReportWriter writes OutdatedImageReports for the workloads of the cluster if enabled.
+func startReportWriter(ctx context.Context, cfg config.Config, evaluator *evaluation.Evaluator, logger *slog.Logger) error {
+	if !cfg.Output.Reports || !slices.Contains(cfg.Sources.Providers, "kubernetes") {
+		return nil
+	}
+
+	restConfig, err := k8s.RestConfig(cfg.Sources.Kubernetes.InCluster)
+	if err != nil {
+		return err
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	writer := imagereport.NewWriter(imagereport.Config{
+		WriteDelay:  10 * time.Second,
+		DeleteDelay: 5 * time.Minute,
+	}, dynamicClient, logger)
+
+	evaluator.AddObserver(writer)
+
+	go writer.Run(ctx)
+
 	return nil
 }
+
+// startEventRecorder records events on workloads whose images fall further behind if enabled.
+func startEventRecorder(ctx context.Context, cfg config.Config, evaluator *evaluation.Evaluator, logger *slog.Logger) error {
+	if !cfg.Output.Events || !slices.Contains(cfg.Sources.Providers, "kubernetes") {
+		return nil
+	}
+
+	restConfig, err := k8s.RestConfig(cfg.Sources.Kubernetes.InCluster)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	eventRecorder, shutdown := events.NewBroadcastRecorder(clientset)
+
+	go func() {
+		<-ctx.Done()
+		shutdown()
+	}()
+
+	evaluator.AddObserver(events.NewRecorder(eventRecorder, func() bool {
+		return evaluator.Health().Initialized
+	}, logger))
+
+	return nil
+}
+
+// startTelemetry exports metrics and traces via OTLP if an endpoint is configured.
+func startTelemetry(ctx context.Context, cfg config.Config, evaluator *evaluation.Evaluator) (func(context.Context) error, error) {
+	if cfg.Telemetry.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	telemetryConfig := telemetry.Config{
+		Endpoint: cfg.Telemetry.Endpoint,
+		Headers:  cfg.Telemetry.Headers,
+		Traces:   cfg.Telemetry.Traces,
+	}
+
+	if cfg.Telemetry.Metrics {
+		telemetryConfig.MetricsInterval = cfg.Telemetry.Interval.Duration
+	}
+
+	return telemetry.Start(ctx, telemetryConfig, evaluator)
+}
+
+// newServerConfig sets up TLS and the access checks of the metrics server. Protected endpoints review tokens and
+// access with the Kubernetes API.
+func newServerConfig(cfg config.Config, logger *slog.Logger) (exporter.ServerConfig, error) {
+	serverConfig := exporter.ServerConfig{
+		Addr: cfg.Output.ListenAddr,
+	}
+
+	if cfg.Output.TLS.CertFile != "" {
+		tlsConfig, err := exporter.NewTLSConfig(cfg.Output.TLS.CertFile, cfg.Output.TLS.KeyFile, cfg.Output.TLS.ClientCAFile)
+		if err != nil {
+			return exporter.ServerConfig{}, err
+		}
+
+		serverConfig.TLS = tlsConfig
+	}
+
+	for _, endpoint := range []struct {
+		access string
+		target *exporter.Access
+	}{
+		{cfg.Output.Access.Metrics, &serverConfig.Access.Metrics},
+		{cfg.Output.Access.API, &serverConfig.Access.API},
+		{cfg.Output.Access.Dashboard, &serverConfig.Access.Dashboard},
+	} {
+		access, err := exporter.ParseAccess(endpoint.access)
+		if err != nil {
+			return exporter.ServerConfig{}, err
+		}
+
+		*endpoint.target = access
+
+		if access != exporter.AccessNone && serverConfig.Authorizer == nil {
+			restConfig, err := k8s.RestConfig(cfg.Sources.Kubernetes.InCluster)
+			if err != nil {
+				return exporter.ServerConfig{}, err
+			}
+
+			clientset, err := kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				return exporter.ServerConfig{}, err
+			}
+
+			serverConfig.Authorizer = exporter.NewAuthorizer(clientset, logger)
+		}
+	}
+
+	return serverConfig, nil
+}
+
+// startNotifier posts notifications to the configured webhook receivers if there are any.
+func startNotifier(ctx context.Context, cfg config.Config, evaluator *evaluation.Evaluator, logger *slog.Logger) error {
+	if len(cfg.Notifications.Receivers) == 0 {
+		return nil
+	}
+
+	receivers := make([]notify.Receiver, 0, len(cfg.Notifications.Receivers))
+
+	for _, receiverConfig := range cfg.Notifications.Receivers {
+		receiver, err := newReceiver(receiverConfig)
+		if err != nil {
+			return fmt.Errorf("receiver %s: %w", receiverConfig.Name, err)
+		}
+
+		receivers = append(receivers, receiver)
+	}
+
+	notifier := notify.NewNotifier(notify.Config{
+		Interval:  cfg.Notifications.Interval.Duration,
+		Receivers: receivers,
+		Initialized: func() bool {
+			return evaluator.Health().Initialized
+		},
+	}, &http.Client{Timeout: 30 * time.Second}, logger)
+
+	evaluator.AddObserver(notifier)
+
+	go notifier.Run(ctx)
+
+	return nil
+}
+
+func newReceiver(cfg config.Receiver) (notify.Receiver, error) {
+	receiver := notify.Receiver{
+		Name:        cfg.Name,
+		URL:         cfg.URL,
+		Headers:     cfg.Headers,
+		Namespaces:  cfg.Namespaces,
+		MinSeverity: evaluation.SeverityPatch,
+		Location:    time.Local,
+		Retries:     3,
+		Backoff:     time.Second,
+	}
+
+	var err error
+
+	receiver.Template, err = notify.ParseTemplate(cfg.Format, cfg.Template)
+	if err != nil {
+		return notify.Receiver{}, err
+	}
+
+	if cfg.MinSeverity != "" {
+		receiver.MinSeverity, err = evaluation.ParseSeverity(cfg.MinSeverity)
+		if err != nil {
+			return notify.Receiver{}, err
+		}
+	}
+
+	if cfg.Timezone != "" {
+		receiver.Location, err = time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return notify.Receiver{}, err
+		}
+	}
+
+	if cfg.QuietHours.Start != "" {
+		var quietHours notify.QuietHours
+
+		quietHours.Start, err = notify.ParseTimeOfDay(cfg.QuietHours.Start)
+		if err != nil {
+			return notify.Receiver{}, err
+		}
+
+		quietHours.End, err = notify.ParseTimeOfDay(cfg.QuietHours.End)
+		if err != nil {
+			return notify.Receiver{}, err
+		}
+
+		receiver.QuietHours = &quietHours
+	}
+
+	if cfg.DailySummary != "" {
+		receiver.DailySummary = true
+
+		receiver.SummaryAt, err = notify.ParseTimeOfDay(cfg.DailySummary)
+		if err != nil {
+			return notify.Receiver{}, err
+		}
+	}
+
+	if cfg.Retries != nil {
+		receiver.Retries = *cfg.Retries
+	}
+
+	return receiver, nil
+}
+
+// restoreState loads the saved state into the evaluator and keeps saving it if a state store is configured. The
+// returned channel is closed after the last save once the context is done.
+func restoreState(ctx context.Context, cfg config.Config, evaluator *evaluation.Evaluator, logger *slog.Logger) (<-chan struct{}, error) {
+	saved := make(chan struct{})
+
+	store, closeStore, err := newStateStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if store == nil {
+		close(saved)
+
+		return saved, nil
+	}
+
+	snapshot, err := store.Load(ctx)
+	if err != nil {
+		logger.Warn("error loading state, starting without it", "error", err)
+	}
+
+	if snapshot != nil {
+		evaluator.Restore(snapshot)
+
+		logger.Info("restored state", "results", len(snapshot.Results), "savedAt", snapshot.SavedAt)
+
+		time.AfterFunc(cfg.State.ExpireAfter.Duration, func() {
+			expired := evaluator.ExpireRestored()
+			if expired > 0 {
+				logger.Info("dropped restored results that weren't refreshed", "count", expired)
+			}
+		})
+	}
+
+	go func() {
+		defer close(saved)
+		defer closeStore()
+
+		state.Persist(ctx, store, evaluator, cfg.State.Interval.Duration, logger)
+	}()
+
+	return saved, nil
+}
+
+// newStateStore opens the configured state store, nil if persistence is disabled.
+func newStateStore(cfg config.Config) (state.Store, func(), error) {
+	switch cfg.State.Store {
+	case "file":
+		store, err := state.NewFileStore(cfg.State.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening state file: %w", err)
+		}
+
+		return store, func() { _ = store.Close() }, nil
+	case "configmap":
+		restConfig, err := k8s.RestConfig(cfg.Sources.Kubernetes.InCluster)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		clientset, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		namespace := cfg.State.ConfigMap.Namespace
+		if namespace == "" {
+			namespace = podNamespace()
+		}
+
+		return state.NewConfigMapStore(clientset, namespace, cfg.State.ConfigMap.Name), func() {}, nil
+	}
+
+	return nil, nil, nil
+}
+
+// podNamespace returns the namespace of the exporter's service account, default outside of a cluster.
+func podNamespace() string {
+	namespace, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if err != nil {
+		return "default"
+	}
+
+	return strings.TrimSpace(string(namespace))
+}
+
+// newPolicyStore watches the ImageUpdatePolicies of the cluster when the kubernetes provider is used. It returns nil
+// if policies are disabled or the CRD is not installed.
+func newPolicyStore(ctx context.Context, cfg config.Config, logger *slog.Logger) (*policy.Store, error) {
+	if !cfg.Sources.Kubernetes.Policies || !slices.Contains(cfg.Sources.Providers, "kubernetes") {
+		return nil, nil
+	}
+
+	restConfig, err := k8s.RestConfig(cfg.Sources.Kubernetes.InCluster)
+	if err != nil {
+		return nil, err
+	}
+
+	store := policy.NewStore()
+
+	err = policy.Watch(ctx, restConfig, store, cfg.Sources.Kubernetes.InformerResyncInterval.Duration, logger)
+	if errors.Is(err, policy.ErrNotInstalled) {
+		logger.Info("ImageUpdatePolicy CRD not installed, using annotations only", "error", err)
+
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// newKeychain merges the credentials file with the credentials given per registry in the configuration.
+func newKeychain(auth config.Auth, logger *slog.Logger) *tags.DockerConfigKeychain {
+	fileKeychain, err := tags.ReadRegistryCredentialsFromFile(auth.CredentialsFile)
+	if err != nil {
+		logger.Warn("no registry auth provided. continuing without registry auth", "path", auth.CredentialsFile, "error", err)
+	}
+
+	authConfigs := map[string]authn.AuthConfig{}
+	for host, credentials := range auth.Registries {
+		authConfigs[host] = authn.AuthConfig{
+			Username:      credentials.Username,
+			Password:      credentials.Password,
+			IdentityToken: credentials.IdentityToken,
+		}
+	}
+
+	return tags.MergeKeychains(fileKeychain, tags.KeychainFromAuthConfigs(authConfigs))
+}
+
+func newTagListerOptions(cfg config.Config) tags.Options {
+	var options tags.Options
+
+	for _, rewrite := range cfg.Rewrites {
+		options.Rewrites = append(options.Rewrites, tags.Rewrite{
+			From: rewrite.From,
+			To:   rewrite.To,
+		})
+	}
+
+	for _, registry := range cfg.Registries {
+		if registry.Insecure {
+			options.InsecureRegistries = append(options.InsecureRegistries, registry.Host)
+		}
+	}
+
+	return options
+}
+
+func newTagLister(cfg config.Config, logger *slog.Logger) (*tags.TagLister, error) {
+	tagLister, err := tags.NewTagLister(newKeychain(cfg.Auth, logger))
+	if err != nil {
+		return nil, err
+	}
+
+	tagLister.SetOptions(newTagListerOptions(cfg))
+
+	return tagLister, nil
+}
+
+// newEvaluator sets up the container sources and the evaluator checking their images. With once, the cluster
+// sources report every container a single time instead of watching for changes.
+func newEvaluator(
+	cfg config.Config,
+	tagLister *tags.TagLister,
+	policies *policy.Store,
+	once bool,
+	logger *slog.Logger,
+) (*evaluation.Evaluator, error) {
+	if len(cfg.Sources.Providers) == 0 {
+		return nil, errors.New("no container provider configured")
+	}
+
+	sources := make([]clients.Source, 0, len(cfg.Sources.Providers))
+
+	for _, provider := range cfg.Sources.Providers {
+		source, err := newContainerClient(provider, cfg.Sources, once, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, source)
+	}
+
+	var client evaluation.ContainerClient = clients.NewMultiClient(sources...)
+	if len(sources) == 1 {
+		client = sources[0]
+	}
+
+	evaluationConfig, err := newEvaluationConfig(cfg, policies)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluationConfig.Watch = !once
+
+	versionChecker, err := version.NewChecker()
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluation.NewEvaluator(evaluationConfig, tagLister, versionChecker, client, logger)
+}
+
+func newContainerClient(provider string, sources config.Sources, once bool, logger *slog.Logger) (clients.Source, error) {
+	switch provider {
+	case "kubernetes":
+		return k8s.NewContainerClient(k8s.ConnectionConfig{
+			InClusterConfig:        sources.Kubernetes.InCluster,
+			InformerResyncInterval: sources.Kubernetes.InformerResyncInterval.Duration,
+			ImageCheckInterval:     sources.ImageCheckInterval.Duration,
+			Once:                   once,
+		}, logger)
+	case "docker":
+		return docker.NewDockerClient(docker.Config{
+			ListSwarmServices:  sources.Docker.SwarmServices,
+			ImageCheckInterval: sources.ImageCheckInterval.Duration,
+			Once:               once,
+		}, logger)
+	case "manifest":
+		return manifest.NewContainerClient(manifest.Config{
+			Paths: sources.Paths,
+		}, logger)
+	case "dockerfile":
+		return dockerfile.NewContainerClient(dockerfile.Config{
+			Paths: sources.Paths,
+		}, logger)
+	case "compose":
+		return compose.NewContainerClient(compose.Config{
+			Paths: sources.Paths,
+		}, logger)
+	}
+
+	return nil, fmt.Errorf("unsupported provider %q", provider)
+}
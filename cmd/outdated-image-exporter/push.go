@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/config"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/policy"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/push"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+)
+
+var pushFlags = flag.NewFlagSet("push", flag.ExitOnError)
+var pushGateway = pushFlags.String("pushgateway", "", "URL of a Prometheus Pushgateway, e.g. http://pushgateway:9091")
+var pushRemoteWrite = pushFlags.String("remote-write", "", "URL of a Prometheus remote-write endpoint, e.g. http://prometheus:9090/api/v1/write")
+var pushJob = pushFlags.String("job", "outdated-image-exporter", "Job of the pushed metrics")
+var pushGrouping = pushFlags.String("grouping", "", "Comma separated grouping key added to the job, e.g. runner=ci,pipeline=web")
+var pushInterval = pushFlags.Duration("interval", 0, "Check and push again in this interval until SIGTERM. Zero pushes once.")
+
+func init() {
+	pushFlags.Usage = func() {
+		_, _ = fmt.Fprintf(pushFlags.Output(), "Usage: %s push [flags] [paths...]\n\nChecks every image of the configured sources and pushes the metrics to a Pushgateway or remote-write endpoint.\n\n", os.Args[0])
+		pushFlags.PrintDefaults()
+	}
+
+	shareFlags(pushFlags, "config", "container", "paths", "registry-credentials", "in-cluster", "docker-swarm-services", "log-level")
+}
+
+// runPush evaluates every image of the configured sources and pushes the container metrics, once or in an
+// interval. Every push replaces the previous results of the job and grouping key.
+func runPush(args []string) error {
+	paths, err := parseInterspersed(pushFlags, args)
+	if err != nil {
+		return err
+	}
+
+	grouping, err := parseGrouping(*pushGrouping)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	var targets []push.Target
+
+	if *pushGateway != "" {
+		target, err := push.NewPushgateway(*pushGateway, *pushJob, grouping, client)
+		if err != nil {
+			return err
+		}
+
+		targets = append(targets, target)
+	}
+
+	if *pushRemoteWrite != "" {
+		target, err := push.NewRemoteWrite(*pushRemoteWrite, *pushJob, grouping, client)
+		if err != nil {
+			return err
+		}
+
+		targets = append(targets, target)
+	}
+
+	if len(targets) == 0 {
+		pushFlags.Usage()
+
+		return errors.New("-pushgateway or -remote-write is required")
+	}
+
+	cfg, err := loadConfig(pushFlags)
+	if err != nil {
+		return err
+	}
+
+	err = push.ValidateGrouping(grouping, cfg.Output.MetricLabels)
+	if err != nil {
+		return err
+	}
+
+	cfg.Sources.Paths = append(cfg.Sources.Paths, paths...)
+
+	logger, err := newLogger(cfg.Output)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer cancel()
+
+	tagLister, err := newTagLister(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	policies, err := newPolicyStore(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	for {
+		err = pushResults(ctx, cfg, tagLister, policies, targets, logger)
+		if *pushInterval <= 0 {
+			return err
+		}
+
+		if err != nil {
+			logger.Error("failed to push results", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*pushInterval):
+		}
+	}
+}
+
+// pushResults checks every image once with a fresh evaluator, so containers that are gone are not pushed again. The
+// sources of the evaluator, e.g. the pod informer, are stopped before it returns.
+func pushResults(
+	ctx context.Context,
+	cfg config.Config,
+	tagLister *tags.TagLister,
+	policies *policy.Store,
+	targets []push.Target,
+	logger *slog.Logger,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	evaluator, err := newEvaluator(cfg, tagLister, policies, true, logger)
+	if err != nil {
+		return err
+	}
+
+	err = evaluator.Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, 0, len(targets))
+	for _, target := range targets {
+		errs = append(errs, target.Push(ctx, evaluator))
+	}
+
+	err = errors.Join(errs...)
+	if err == nil {
+		logger.Info("pushed results", "containers", len(evaluator.Results()))
+	}
+
+	return err
+}
+
+// parseGrouping parses a grouping key like runner=ci,pipeline=web.
+func parseGrouping(grouping string) (map[string]string, error) {
+	labels := map[string]string{}
+
+	for _, pair := range splitList(grouping) {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("invalid grouping %q, expected name=value", pair)
+		}
+
+		labels[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	return labels, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/report"
+)
+
+var scanFlags = flag.NewFlagSet("scan", flag.ExitOnError)
+var scanFormat = scanFlags.String("format", "table", "Output format: [table, json, sarif, junit]")
+var scanFailOn = scanFlags.String("fail-on", "", "Exit with code 2 if an image is outdated by at least this much: [major, minor, patch]")
+var scanFailOnError = scanFlags.Bool("fail-on-error", false, "Exit with code 2 if an image could not be checked, e.g. because its registry can't be reached")
+var scanOutput = scanFlags.String("output", "", "File to write the results to. Defaults to stdout.")
+
+func init() {
+	scanFlags.Usage = func() {
+		_, _ = fmt.Fprintf(scanFlags.Output(), "Usage: %s scan [flags] [paths...]\n\nChecks every image of the configured sources once and prints the results.\n\n", os.Args[0])
+		scanFlags.PrintDefaults()
+	}
+
+	shareFlags(scanFlags, "config", "container", "paths", "registry-credentials", "in-cluster", "docker-swarm-services", "log-level")
+}
+
+// runScan evaluates every image of the configured sources once, waits until all of them are checked and writes a
+// report.
+func runScan(args []string) error {
+	paths, err := parseInterspersed(scanFlags, args)
+	if err != nil {
+		return err
+	}
+
+	format, err := report.ParseFormat(*scanFormat)
+	if err != nil {
+		return err
+	}
+
+	failOn := evaluation.SeverityNone
+	if *scanFailOn != "" {
+		failOn, err = evaluation.ParseSeverity(*scanFailOn)
+		if err != nil {
+			return err
+		}
+	}
+
+	cfg, err := loadConfig(scanFlags)
+	if err != nil {
+		return err
+	}
+
+	cfg.Sources.Paths = append(cfg.Sources.Paths, paths...)
+
+	logger, err := newLogger(cfg.Output)
+	if err != nil {
+		return err
+	}
+
+	tagLister, err := newTagLister(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	policies, err := newPolicyStore(context.Background(), cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	evaluator, err := newEvaluator(cfg, tagLister, policies, true, logger)
+	if err != nil {
+		return err
+	}
+
+	err = evaluator.Run(context.Background())
+	if err != nil {
+		return err
+	}
+
+	results := evaluator.Results()
+
+	var output io.Writer = os.Stdout
+
+	if *scanOutput != "" {
+		file, err := os.Create(*scanOutput)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		output = file
+	}
+
+	err = report.Write(output, format, results)
+	if err != nil {
+		return err
+	}
+
+	return checkResults(results, failOn, *scanFailOnError)
+}
+
+// checkResults fails if an image is outdated by at least failOn, or if failOnError is set and an image could not be
+// checked. SeverityNone disables the severity check.
+func checkResults(results []evaluation.Result, failOn evaluation.Severity, failOnError bool) error {
+	for _, result := range results {
+		if failOnError && result.Err != nil {
+			return fmt.Errorf("%w: %s: %v", errCheckFailed, result.ContainerImage.Image, result.Err)
+		}
+
+		if failOn != evaluation.SeverityNone && result.Severity() >= failOn {
+			return fmt.Errorf("%w: %s is outdated by at least one %s version", errOutdatedImages, result.ContainerImage.Image, failOn)
+		}
+	}
+
+	return nil
+}
+
+// shareFlags makes flags of the default command available to a subcommand as well.
+func shareFlags(flagSet *flag.FlagSet, names ...string) {
+	for _, name := range names {
+		defaultFlag := flag.Lookup(name)
+
+		flagSet.Var(defaultFlag.Value, defaultFlag.Name, defaultFlag.Usage)
+	}
+}
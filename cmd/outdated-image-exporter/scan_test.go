@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+func TestCheckResults(t *testing.T) {
+	failed := evaluation.Result{
+		ContainerImage: clients.ContainerImage{Image: "registry.internal/app:1.0.0"},
+		Err:            errors.New("connection refused"),
+	}
+	outdated := evaluation.Result{
+		ContainerImage: clients.ContainerImage{Image: "nginx:1.24.0"},
+		Evaluation:     version.Evaluation{Minor: 1},
+	}
+
+	require.NoError(t, checkResults([]evaluation.Result{failed}, evaluation.SeverityMajor, false))
+	require.ErrorIs(t, checkResults([]evaluation.Result{failed}, evaluation.SeverityNone, true), errCheckFailed)
+	require.ErrorIs(t, checkResults([]evaluation.Result{failed}, evaluation.SeverityMajor, true), errCheckFailed)
+
+	require.NoError(t, checkResults([]evaluation.Result{outdated}, evaluation.SeverityMajor, true))
+	require.NoError(t, checkResults([]evaluation.Result{outdated}, evaluation.SeverityNone, false))
+	require.ErrorIs(t, checkResults([]evaluation.Result{outdated}, evaluation.SeverityMinor, false), errOutdatedImages)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/files"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/update"
+)
+
+var updateFlags = flag.NewFlagSet("update", flag.ExitOnError)
+var updateProviders = updateFlags.String("container", "manifest,dockerfile,compose", "Comma separated list of file sources to update: [manifest, dockerfile, compose]")
+var updatePinMode = updateFlags.String("pin-mode", "", "Default pin mode for images without pin-mode annotation: [none, major, minor]. Overrides the policy of the config file.")
+var updateConstraint = updateFlags.String("constraint", "", "Default version constraint for images without constraint annotation, e.g. \"< 2.0\". Overrides the policy of the config file.")
+var updateDiff = updateFlags.Bool("diff", false, "Write a unified diff instead of editing the files")
+var updateOutput = updateFlags.String("output", "", "File to write the diff to. Defaults to stdout.")
+
+func init() {
+	updateFlags.Usage = func() {
+		_, _ = fmt.Fprintf(updateFlags.Output(), "Usage: %s update [flags] [paths...]\n\nRewrites image references in manifests and Dockerfiles to the newest allowed tag.\n\n", os.Args[0])
+		updateFlags.PrintDefaults()
+	}
+
+	shareFlags(updateFlags, "config", "paths", "registry-credentials", "log-level")
+}
+
+// runUpdate checks every image of the given files once and rewrites outdated references in place, or prints a
+// diff of the changes.
+func runUpdate(args []string) error {
+	paths, err := parseInterspersed(updateFlags, args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(updateFlags)
+	if err != nil {
+		return err
+	}
+
+	cfg.Sources.Providers = splitList(*updateProviders)
+	cfg.Sources.Paths = append(cfg.Sources.Paths, paths...)
+
+	if *updatePinMode != "" {
+		cfg.Policy.PinMode = *updatePinMode
+	}
+
+	if *updateConstraint != "" {
+		cfg.Policy.Constraint = *updateConstraint
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return err
+	}
+
+	logger, err := newLogger(cfg.Output)
+	if err != nil {
+		return err
+	}
+
+	tagLister, err := newTagLister(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	evaluator, err := newEvaluator(cfg, tagLister, nil, true, logger)
+	if err != nil {
+		return err
+	}
+
+	err = evaluator.Run(context.Background())
+	if err != nil {
+		return err
+	}
+
+	edits := map[string][]update.Edit{}
+
+	for _, result := range evaluator.Results() {
+		edit, ok := update.EditForResult(result)
+		if !ok {
+			if update.Templated(result) && result.Err == nil && result.Latest != "" {
+				logger.Warn("skipping image reference whose tag is set by a variable", "name", result.ContainerImage.Name, "image", result.ContainerImage.Image, "latest", result.Latest)
+			}
+
+			continue
+		}
+
+		if edit.File == files.Stdin {
+			logger.Warn("can't update images read from stdin", "image", edit.Old)
+
+			continue
+		}
+
+		// stages sharing an ARG share its edit
+		if !slices.Contains(edits[edit.File], edit) {
+			edits[edit.File] = append(edits[edit.File], edit)
+		}
+	}
+
+	fileNames := make([]string, 0, len(edits))
+	for file := range edits {
+		fileNames = append(fileNames, file)
+	}
+
+	sort.Strings(fileNames)
+
+	var output io.Writer = os.Stdout
+
+	if *updateDiff && *updateOutput != "" {
+		file, err := os.Create(*updateOutput)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		output = file
+	}
+
+	var skipped error
+
+	for _, fileName := range fileNames {
+		err = updateFile(fileName, edits[fileName], output)
+		if errors.Is(err, update.ErrNotFound) {
+			// keep updating the other files, but fail the command once all of them are written
+			skipped = errors.Join(skipped, err)
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if skipped != nil {
+		return fmt.Errorf("some image references could not be updated:\n%w", skipped)
+	}
+
+	return nil
+}
+
+// updateFile writes the edits that could be applied to a file, or their diff. Edits that couldn't be applied are
+// returned as error after the file was written.
+func updateFile(fileName string, edits []update.Edit, diffOutput io.Writer) error {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return err
+	}
+
+	before, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	after, applied, applyErr := update.Apply(before, edits)
+
+	if *updateDiff {
+		diff, err := update.Diff(fileName, before, after)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(diffOutput, diff)
+		if err != nil {
+			return err
+		}
+
+		return applyErr
+	}
+
+	for _, edit := range applied {
+		_, _ = fmt.Fprintf(os.Stderr, "%s:%d: %s -> %s\n", edit.File, edit.Line, edit.Old, edit.New)
+	}
+
+	err = os.WriteFile(fileName, after, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	return applyErr
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/dockerfile"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/update"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+func TestUpdateFile_Skipped(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "deployment.yaml")
+
+	err := os.WriteFile(fileName, []byte("image: nginx:1.25.0\nimage: {{ .Values.image }}\n"), 0o600)
+	require.NoError(t, err)
+
+	err = updateFile(fileName, []update.Edit{
+		{File: fileName, Line: 1, Column: 8, Old: "nginx:1.25.0", New: "nginx:1.27.0"},
+		{File: fileName, Line: 2, Column: 8, Old: "redis:7.0.0", New: "redis:7.2.0"},
+	}, io.Discard)
+	require.ErrorIs(t, err, update.ErrNotFound)
+
+	content, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	require.Equal(t, "image: nginx:1.27.0\nimage: {{ .Values.image }}\n", string(content))
+}
+
+func TestUpdateFile_Arg(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "Dockerfile")
+	content := "ARG NGINX_VERSION=\"1.24\"\nFROM nginx:${NGINX_VERSION} AS web\nFROM nginx:$NGINX_VERSION\n"
+
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0o600))
+
+	containerImages, err := dockerfile.Parse(fileName, []byte(content))
+	require.NoError(t, err)
+	require.Len(t, containerImages, 2)
+
+	var edits []update.Edit
+
+	for _, containerImage := range containerImages {
+		require.Equal(t, "nginx:1.24", containerImage.Image)
+
+		edit, ok := update.EditForResult(evaluation.Result{
+			ContainerImage: containerImage,
+			Evaluation:     version.Evaluation{Latest: "1.26"},
+		})
+		require.True(t, ok)
+
+		edits = append(edits, edit)
+	}
+
+	// Both stages use the default of the ARG
+	require.Equal(t, edits[0], edits[1])
+	require.NoError(t, updateFile(fileName, edits[:1], io.Discard))
+
+	updated, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	require.Equal(t, "ARG NGINX_VERSION=\"1.26\"\nFROM nginx:${NGINX_VERSION} AS web\nFROM nginx:$NGINX_VERSION\n", string(updated))
+}
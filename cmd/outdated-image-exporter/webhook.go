@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/policy"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/webhook"
+)
+
+var webhookFlags = flag.NewFlagSet("webhook", flag.ExitOnError)
+var webhookAddr = webhookFlags.String("addr", ":8443", "The address to serve the admission webhook on")
+var webhookCert = webhookFlags.String("tls-cert", "", "Path to the TLS certificate of the webhook")
+var webhookKey = webhookFlags.String("tls-key", "", "Path to the TLS private key of the webhook")
+var webhookWarn = webhookFlags.String("warn", "major=1,minor=1", "Return a warning for new images at least this far behind, e.g. major=1,minor=3. Empty disables warnings.")
+var webhookDeny = webhookFlags.String("deny", "", "Reject objects with new images at least this far behind, e.g. major=2. Empty disables rejections.")
+var webhookTimeout = webhookFlags.Duration("timeout", 5*time.Second, "Time to check the images of an object. Images not checked in time are admitted with a warning.")
+
+func init() {
+	webhookFlags.Usage = func() {
+		_, _ = fmt.Fprintf(webhookFlags.Output(), "Usage: %s webhook [flags]\n\nServes a validating admission webhook warning about or rejecting outdated images.\n\n", os.Args[0])
+		webhookFlags.PrintDefaults()
+	}
+
+	shareFlags(webhookFlags, "config", "registry-credentials", "in-cluster", "log-level")
+}
+
+// runWebhook serves AdmissionReviews until SIGTERM. Images are checked with the default policy, ImageUpdatePolicies
+// and annotations like in the exporter.
+func runWebhook(args []string) error {
+	err := webhookFlags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *webhookCert == "" || *webhookKey == "" {
+		webhookFlags.Usage()
+
+		return errors.New("-tls-cert and -tls-key are required")
+	}
+
+	warn, err := policy.ParseThresholds(*webhookWarn)
+	if err != nil {
+		return err
+	}
+
+	deny, err := policy.ParseThresholds(*webhookDeny)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(webhookFlags)
+	if err != nil {
+		return err
+	}
+
+	// The webhook only admits Kubernetes objects, policies come from the same cluster
+	cfg.Sources.Providers = []string{"kubernetes"}
+
+	logger, err := newLogger(cfg.Output)
+	if err != nil {
+		return err
+	}
+
+	tagLister, err := newTagLister(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	policies, err := newPolicyStore(runCtx, cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	evaluationConfig, err := newEvaluationConfig(cfg, policies)
+	if err != nil {
+		return err
+	}
+
+	versionChecker, err := version.NewChecker()
+	if err != nil {
+		return err
+	}
+
+	evaluator, err := evaluation.NewEvaluator(evaluationConfig, tagLister, versionChecker, nil, logger)
+	if err != nil {
+		return err
+	}
+
+	handler := webhook.NewHandler(webhook.Config{
+		Warn:    warn,
+		Deny:    deny,
+		Timeout: *webhookTimeout,
+	}, evaluator, logger)
+
+	shutdownFunc, err := webhook.RunServer(*webhookAddr, *webhookCert, *webhookKey, handler, logger)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("serving admission webhook", "addr", *webhookAddr)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+
+	<-signals
+
+	cancel()
+
+	return shutdownFunc()
+}
@@ -1,10 +1,16 @@
 package clients
 
+import "strings"
+
 type Action int
 
 const (
 	ContainerImageAdded Action = iota
 	ContainerImageRemoved
+
+	// ContainerImagesListed marks that a source reported every container image it found when it started. It
+	// carries no image and is sent once.
+	ContainerImagesListed
 )
 
 func (a Action) String() string {
@@ -13,11 +19,40 @@ func (a Action) String() string {
 		return "ContainerImageAdded"
 	case ContainerImageRemoved:
 		return "ContainerImageRemoved"
+	case ContainerImagesListed:
+		return "ContainerImagesListed"
 	}
 
 	return "Unknown"
 }
 
+// Well-known keys of ContainerImage.Metadata
+const (
+	// MetadataDockerKeychain holds a *tags.DockerConfigKeychain with source specific registry credentials
+	MetadataDockerKeychain = "DockerKeychain"
+
+	// MetadataFile, MetadataLine and MetadataColumn locate the image reference in a scanned file
+	MetadataFile   = "File"
+	MetadataLine   = "Line"
+	MetadataColumn = "Column"
+
+	// MetadataReference holds the text at MetadataLine and MetadataColumn if the image was expanded from variables,
+	// e.g. nginx:${NGINX_VERSION}, or just 1.25 if the whole tag is the default of a Dockerfile ARG written there
+	MetadataReference = "Reference"
+
+	// MetadataNamespace holds the Kubernetes namespace of the pod running the image
+	MetadataNamespace = "Namespace"
+
+	// MetadataWorkload holds the k8s.Workload owning the pod running the image
+	MetadataWorkload = "Workload"
+
+	// MetadataContainer holds the name of the container inside its pod
+	MetadataContainer = "Container"
+
+	// MetadataDigest holds the digest of the image the container runs, e.g. sha256:...
+	MetadataDigest = "Digest"
+)
+
 type ContainerImage struct {
 	Action Action
 
@@ -36,3 +71,14 @@ type ContainerImage struct {
 	// Image reference, including registry, name and tag
 	Image string
 }
+
+// DigestOf returns the digest of an image reference or a pod's image ID like docker-pullable://nginx@sha256:...,
+// empty if it has none.
+func DigestOf(reference string) string {
+	_, digest, found := strings.Cut(reference, "@")
+	if !found {
+		return ""
+	}
+
+	return digest
+}
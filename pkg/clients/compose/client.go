@@ -0,0 +1,46 @@
+package compose
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/files"
+)
+
+type Config struct {
+	// Paths to Compose files or directories containing them
+	Paths []string
+}
+
+// ContainerClient reports the images of Compose files once and closes the channel afterwards.
+type ContainerClient struct {
+	Config Config
+
+	logger *slog.Logger
+}
+
+func NewContainerClient(config Config, logger *slog.Logger) (*ContainerClient, error) {
+	return &ContainerClient{
+		Config: config,
+		logger: logger,
+	}, nil
+}
+
+func (c *ContainerClient) Listener(ctx context.Context) (<-chan clients.ContainerImage, error) {
+	return files.Listener(ctx, c.Config.Paths, isComposeFile, Parse)
+}
+
+func isComposeFile(name string) bool {
+	extension := filepath.Ext(name)
+	if extension != ".yml" && extension != ".yaml" {
+		return false
+	}
+
+	baseName := strings.TrimSuffix(name, extension)
+
+	return baseName == "compose" || baseName == "docker-compose" ||
+		strings.HasPrefix(baseName, "compose.") || strings.HasPrefix(baseName, "docker-compose.")
+}
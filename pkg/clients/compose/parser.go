@@ -0,0 +1,162 @@
+package compose
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/files"
+)
+
+// Parse extracts the images of all services in a Compose file. Variables are interpolated from the environment
+// and a .env file next to the Compose file. Services that are only built and images depending on unset
+// variables are skipped.
+func Parse(file string, content []byte) ([]clients.ContainerImage, error) {
+	var document yaml.Node
+
+	err := yaml.Unmarshal(content, &document)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return nil, nil
+	}
+
+	services := mappingValue(document.Content[0], "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	lookup := environmentLookup(file)
+
+	var containerImages []clients.ContainerImage
+
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		serviceName := services.Content[i].Value
+
+		image := mappingValue(services.Content[i+1], "image")
+		if image == nil || image.Kind != yaml.ScalarNode || image.Value == "" {
+			continue
+		}
+
+		imageReference, complete := files.Expand(image.Value, lookup)
+		if !complete {
+			continue
+		}
+
+		path := "services." + serviceName + ".image"
+
+		metadata := map[string]interface{}{
+			clients.MetadataFile:   file,
+			clients.MetadataLine:   image.Line,
+			clients.MetadataColumn: image.Column,
+		}
+
+		if imageReference != image.Value {
+			metadata[clients.MetadataReference] = image.Value
+		}
+
+		containerImages = append(containerImages, clients.ContainerImage{
+			Action:   clients.ContainerImageAdded,
+			Name:     file + ":" + path,
+			Metadata: metadata,
+			Labels: map[string]string{
+				"file":    file,
+				"path":    path,
+				"service": serviceName,
+			},
+			Annotations: serviceLabels(mappingValue(services.Content[i+1], "labels")),
+			Image:       imageReference,
+		})
+	}
+
+	return containerImages, nil
+}
+
+// serviceLabels reads service labels, which Compose allows either as a mapping or as a list of key=value.
+func serviceLabels(node *yaml.Node) map[string]string {
+	labels := map[string]string{}
+
+	if node == nil {
+		return labels
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			labels[node.Content[i].Value] = node.Content[i+1].Value
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			key, value, _ := strings.Cut(item.Value, "=")
+			labels[key] = value
+		}
+	}
+
+	return labels
+}
+
+// environmentLookup resolves variables from the process environment first, then from the .env file in the
+// directory of the Compose file.
+func environmentLookup(file string) func(name string) (string, bool) {
+	dotEnv := map[string]string{}
+
+	if file != files.Stdin {
+		content, err := os.ReadFile(filepath.Join(filepath.Dir(file), ".env"))
+		if err == nil {
+			dotEnv = parseDotEnv(content)
+		}
+	}
+
+	return func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+
+		value, ok := dotEnv[name]
+
+		return value, ok
+	}
+}
+
+func parseDotEnv(content []byte) map[string]string {
+	variables := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !found {
+			continue
+		}
+
+		variables[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+
+	return variables
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
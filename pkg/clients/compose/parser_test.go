@@ -0,0 +1,31 @@
+package compose_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/compose"
+)
+
+func TestParse(t *testing.T) {
+	content, err := os.ReadFile("testdata/docker-compose.yml")
+	require.NoError(t, err)
+
+	containerImages, err := compose.Parse("testdata/docker-compose.yml", content)
+	require.NoError(t, err)
+	require.Len(t, containerImages, 2)
+
+	web := containerImages[0]
+	require.Equal(t, "nginx:1.25.3", web.Image)
+	require.Equal(t, "testdata/docker-compose.yml:services.web.image", web.Name)
+	require.Equal(t, map[string]string{"file": "testdata/docker-compose.yml", "path": "services.web.image", "service": "web"}, web.Labels)
+	require.Equal(t, "minor", web.Annotations["outdated-images.patrick246.de/pin-mode"])
+	require.Equal(t, 3, web.Metadata[clients.MetadataLine])
+
+	db := containerImages[1]
+	require.Equal(t, "postgres:16.1", db.Image)
+	require.Equal(t, "storage", db.Annotations["com.example.team"])
+}
@@ -4,28 +4,55 @@ import (
 	"context"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/client"
+	"k8s.io/client-go/util/workqueue"
 
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
 )
 
+// listedKey is queued after the services of the containers running at the start. Service keys are never empty.
+const listedKey = ""
+
+type Config struct {
+	// ListSwarmServices additionally lists all Swarm services through the API, including those whose tasks run
+	// on other nodes. The Docker host needs to be a Swarm manager for this.
+	ListSwarmServices bool
+
+	// ImageCheckInterval controls how often the images of running services are checked again.
+	ImageCheckInterval time.Duration
+
+	// Once reports every running service a single time and closes the channel afterwards
+	Once bool
+}
+
 type ContainerClient struct {
-	client *client.Client
-	logger *slog.Logger
+	Config Config
+
+	client    *client.Client
+	workqueue workqueue.DelayingInterface
+	logger    *slog.Logger
+
+	services *serviceGroups
 }
 
-func NewDockerClient(logger *slog.Logger) (*ContainerClient, error) {
+func NewDockerClient(config Config, logger *slog.Logger) (*ContainerClient, error) {
 	dockerClient, err := client.NewClientWithOpts(client.FromEnv)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ContainerClient{
-		client: dockerClient,
-		logger: logger,
+		Config:    config,
+		client:    dockerClient,
+		workqueue: workqueue.NewDelayingQueueWithConfig(workqueue.DelayingQueueConfig{Name: "docker"}),
+		logger:    logger,
+		services:  newServiceGroups(),
 	}, nil
 }
 
@@ -39,41 +66,121 @@ func (c *ContainerClient) Listener(ctx context.Context) (<-chan clients.Containe
 		return nil, err
 	}
 
+	var services []swarm.Service
+	if c.Config.ListSwarmServices {
+		services, err = c.client.ServiceList(ctx, types.ServiceListOptions{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Config.Once {
+		for _, service := range services {
+			c.handleServiceUpdated(service)
+		}
+
+		for _, container := range containers {
+			c.handleCreated(ctx, container.ID, firstNameOrID(container), container.Image)
+		}
+
+		go func() {
+			defer close(containerImageChannel)
+
+			for _, key := range c.services.keys() {
+				containerImage, _ := c.services.get(key)
+
+				select {
+				case containerImageChannel <- containerImage:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case containerImageChannel <- clients.ContainerImage{Action: clients.ContainerImagesListed}:
+			case <-ctx.Done():
+			}
+		}()
+
+		return containerImageChannel, nil
+	}
+
+	go func() {
+		<-ctx.Done()
+		c.workqueue.ShutDown()
+	}()
+
+	go func() {
+		defer close(containerImageChannel)
+
+		for key, quit := c.workqueue.Get(); !quit; key, quit = c.workqueue.Get() {
+			if key == listedKey {
+				c.workqueue.Done(key)
+
+				containerImageChannel <- clients.ContainerImage{Action: clients.ContainerImagesListed}
+
+				continue
+			}
+
+			containerImageChannel <- c.processWorkqueue(key.(string))
+		}
+	}()
+
 	go func() {
+		for _, service := range services {
+			c.handleServiceUpdated(service)
+		}
+
 		for _, container := range containers {
 			c.logger.Debug("container info", "container", container)
 
-			c.handleCreated(ctx, containerImageChannel, container.ID, firstNameOrID(container), container.Image)
+			c.handleCreated(ctx, container.ID, firstNameOrID(container), container.Image)
 		}
 
+		// queued behind the services of the listed containers
+		c.workqueue.Add(listedKey)
+
 		messages, errorChan := c.client.Events(ctx, types.EventsOptions{})
 
 		for {
 			select {
 			case message := <-messages:
-				if message.Type != "container" {
-					continue
-				}
+				c.logger.Debug("docker event", "event", message)
 
-				switch message.Action {
-				case "create":
-					c.handleCreated(ctx, containerImageChannel, message.Actor.ID, message.Actor.Attributes["name"], message.Actor.Attributes["image"])
-				case "die":
-					containerImageChannel <- clients.ContainerImage{
-						Action:      clients.ContainerImageRemoved,
-						Name:        message.Actor.Attributes["name"],
-						Metadata:    nil,
-						Labels:      nil,
-						Annotations: nil,
-						Image:       message.Actor.Attributes["image"],
+				switch message.Type {
+				case events.ContainerEventType:
+					switch message.Action {
+					case events.ActionCreate:
+						c.handleCreated(ctx, message.Actor.ID, message.Actor.Attributes["name"], message.Actor.Attributes["image"])
+					case events.ActionDie:
+						c.handleRemoved(message.Actor.ID, message.Actor.Attributes["name"])
+					}
+				case events.ServiceEventType:
+					if !c.Config.ListSwarmServices {
+						continue
 					}
-				}
 
-				c.logger.Debug("docker event", "event", message)
+					switch message.Action {
+					case events.ActionCreate, events.ActionUpdate:
+						service, _, err := c.client.ServiceInspectWithRaw(ctx, message.Actor.ID, types.ServiceInspectOptions{})
+						if err != nil {
+							c.logger.Warn("error inspecting swarm service", "error", err, "id", message.Actor.ID)
+
+							continue
+						}
+
+						c.handleServiceUpdated(service)
+					case events.ActionRemove:
+						c.handleRemoved(serviceMemberID(message.Actor.ID), message.Actor.Attributes["name"])
+					}
+				}
 			case err := <-errorChan:
 				c.logger.Error("error reading docker events", "error", err)
 
-				break
+				// stops the workqueue, which closes the channel once the queued services are reported
+				c.workqueue.ShutDown()
+
+				return
 			}
 		}
 	}()
@@ -81,20 +188,86 @@ func (c *ContainerClient) Listener(ctx context.Context) (<-chan clients.Containe
 	return containerImageChannel, nil
 }
 
-func (c *ContainerClient) handleCreated(ctx context.Context, containerImageChannel chan<- clients.ContainerImage, containerID, name, image string) {
+// processWorkqueue reports the current image of a service and schedules the next check. Services that are gone
+// by now are reported as removed.
+func (c *ContainerClient) processWorkqueue(key string) clients.ContainerImage {
+	defer c.workqueue.Done(key)
+
+	containerImage, ok := c.services.get(key)
+	if !ok {
+		return clients.ContainerImage{
+			Action: clients.ContainerImageRemoved,
+			Name:   key,
+		}
+	}
+
+	c.logger.Info("checking service", "key", key)
+
+	if c.Config.ImageCheckInterval > 0 {
+		c.workqueue.AddAfter(key, clients.RecheckDelay(c.Config.ImageCheckInterval))
+	}
+
+	return containerImage
+}
+
+func (c *ContainerClient) handleCreated(ctx context.Context, containerID, name, image string) {
 	labels, err := c.getContainerLabels(ctx, containerID)
 	if err != nil {
 		c.logger.Warn("error getting container labels", "error", err, "id", containerID)
 	}
 
-	containerImageChannel <- clients.ContainerImage{
+	key, serviceLabels := logicalService(name, labels)
+
+	c.add(containerID, clients.ContainerImage{
 		Action:      clients.ContainerImageAdded,
-		Name:        name,
-		Metadata:    nil,
-		Labels:      labels,
+		Name:        key,
+		Metadata:    digestMetadata(image),
+		Labels:      serviceLabels,
 		Annotations: labels,
-		Image:       image,
+		Image:       stripDigest(image),
+	})
+}
+
+func (c *ContainerClient) handleServiceUpdated(service swarm.Service) {
+	if service.Spec.TaskTemplate.ContainerSpec == nil {
+		return
 	}
+
+	labels := map[string]string{
+		swarmServiceLabel: service.Spec.Name,
+	}
+
+	for key, value := range service.Spec.Labels {
+		labels[key] = value
+	}
+
+	key, serviceLabels := logicalService(service.Spec.Name, labels)
+
+	c.add(serviceMemberID(service.ID), clients.ContainerImage{
+		Action:      clients.ContainerImageAdded,
+		Name:        key,
+		Metadata:    digestMetadata(service.Spec.TaskTemplate.ContainerSpec.Image),
+		Labels:      serviceLabels,
+		Annotations: labels,
+		Image:       stripDigest(service.Spec.TaskTemplate.ContainerSpec.Image),
+	})
+}
+
+func (c *ContainerClient) add(memberID string, containerImage clients.ContainerImage) {
+	if c.services.add(memberID, containerImage) {
+		c.workqueue.Add(containerImage.Name)
+	}
+}
+
+func (c *ContainerClient) handleRemoved(memberID, name string) {
+	key, known, empty := c.services.remove(memberID)
+	if !known {
+		key = name
+	} else if !empty {
+		return
+	}
+
+	c.workqueue.Add(key)
 }
 
 func (c *ContainerClient) getContainerLabels(ctx context.Context, containerID string) (map[string]string, error) {
@@ -113,3 +286,24 @@ func firstNameOrID(container types.Container) string {
 
 	return container.ID
 }
+
+// stripDigest removes the digest from references like nginx:1.25@sha256:..., which Swarm uses to pin task
+// images. The tag is what gets compared, references without a tag are left untouched.
+func stripDigest(image string) string {
+	withoutDigest, _, found := strings.Cut(image, "@")
+	if !found || !strings.Contains(withoutDigest[strings.LastIndex(withoutDigest, "/")+1:], ":") {
+		return image
+	}
+
+	return withoutDigest
+}
+
+// digestMetadata keeps the digest that stripDigest removes from the image reference.
+func digestMetadata(image string) map[string]interface{} {
+	digest := clients.DigestOf(image)
+	if digest == "" {
+		return nil
+	}
+
+	return map[string]interface{}{clients.MetadataDigest: digest}
+}
@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+)
+
+func TestListener_EventError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.Path, "/containers/json") {
+			_, _ = writer.Write([]byte("[]"))
+
+			return
+		}
+
+		http.Error(writer, `{"message":"daemon shutting down"}`, http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	dockerClient, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")), client.WithVersion("1.44"))
+	require.NoError(t, err)
+
+	containerClient := &ContainerClient{
+		client:    dockerClient,
+		workqueue: workqueue.NewDelayingQueue(),
+		logger:    slog.Default(),
+		services:  newServiceGroups(),
+	}
+
+	containerImages, err := containerClient.Listener(context.Background())
+	require.NoError(t, err)
+
+	var received []clients.ContainerImage
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case containerImage, ok := <-containerImages:
+			if !ok {
+				require.Equal(t, []clients.ContainerImage{{Action: clients.ContainerImagesListed}}, received)
+
+				return
+			}
+
+			received = append(received, containerImage)
+		case <-timeout:
+			t.Fatal("channel not closed after the event stream failed")
+		}
+	}
+}
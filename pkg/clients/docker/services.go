@@ -0,0 +1,146 @@
+package docker
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+)
+
+const (
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+	swarmServiceLabel   = "com.docker.swarm.service.name"
+	stackNamespaceLabel = "com.docker.stack.namespace"
+)
+
+// logicalService returns the name of the service a container belongs to and the labels to export for it.
+// Replicas of a Compose or Swarm service share one name, containers outside a service keep their own name and
+// labels.
+func logicalService(name string, labels map[string]string) (string, map[string]string) {
+	if service, ok := labels[swarmServiceLabel]; ok && service != "" {
+		return service, map[string]string{
+			"project": labels[stackNamespaceLabel],
+			"service": service,
+		}
+	}
+
+	project, hasProject := labels[composeProjectLabel]
+	service, hasService := labels[composeServiceLabel]
+
+	if hasProject && hasService {
+		return project + "/" + service, map[string]string{
+			"project": project,
+			"service": service,
+		}
+	}
+
+	return name, labels
+}
+
+func serviceMemberID(serviceID string) string {
+	return "service:" + serviceID
+}
+
+// serviceGroups keeps track of which containers and Swarm services make up a logical service, so a service is
+// only reported as removed once its last member is gone. It is shared between the event loop and the recheck
+// queue.
+type serviceGroups struct {
+	mutex   sync.Mutex
+	members map[string]string
+	groups  map[string]*serviceGroup
+}
+
+type serviceGroup struct {
+	containerImage clients.ContainerImage
+	members        map[string]struct{}
+}
+
+func newServiceGroups() *serviceGroups {
+	return &serviceGroups{
+		members: map[string]string{},
+		groups:  map[string]*serviceGroup{},
+	}
+}
+
+// add registers a member of a service. It returns true if the service is new or runs a different image now.
+func (s *serviceGroups) add(memberID string, containerImage clients.ContainerImage) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := containerImage.Name
+	s.members[memberID] = key
+
+	group, ok := s.groups[key]
+	if !ok {
+		s.groups[key] = &serviceGroup{
+			containerImage: containerImage,
+			members:        map[string]struct{}{memberID: {}},
+		}
+
+		return true
+	}
+
+	group.members[memberID] = struct{}{}
+
+	if group.containerImage.Image == containerImage.Image {
+		return false
+	}
+
+	group.containerImage = containerImage
+
+	return true
+}
+
+// remove deletes a member. It returns the service key, whether the member was known, and whether the service
+// has no members left.
+func (s *serviceGroups) remove(memberID string) (string, bool, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key, ok := s.members[memberID]
+	if !ok {
+		return "", false, false
+	}
+
+	delete(s.members, memberID)
+
+	group := s.groups[key]
+	delete(group.members, memberID)
+
+	if len(group.members) > 0 {
+		return key, true, false
+	}
+
+	delete(s.groups, key)
+
+	return key, true, true
+}
+
+// get returns the container image currently reported for a service.
+func (s *serviceGroups) get(key string) (clients.ContainerImage, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	group, ok := s.groups[key]
+	if !ok {
+		return clients.ContainerImage{}, false
+	}
+
+	return group.containerImage, true
+}
+
+// keys returns the keys of all services, sorted.
+func (s *serviceGroups) keys() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	keys := make([]string, 0, len(s.groups))
+	for key := range s.groups {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
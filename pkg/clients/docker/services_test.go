@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+)
+
+func TestLogicalService(t *testing.T) {
+	key, labels := logicalService("myproj-web-1", map[string]string{
+		composeProjectLabel: "myproj",
+		composeServiceLabel: "web",
+		"other":             "value",
+	})
+	require.Equal(t, "myproj/web", key)
+	require.Equal(t, map[string]string{"project": "myproj", "service": "web"}, labels)
+
+	key, labels = logicalService("stack_api.1.abcdef", map[string]string{
+		swarmServiceLabel:   "stack_api",
+		stackNamespaceLabel: "stack",
+	})
+	require.Equal(t, "stack_api", key)
+	require.Equal(t, map[string]string{"project": "stack", "service": "stack_api"}, labels)
+
+	key, labels = logicalService("standalone", map[string]string{"app": "x"})
+	require.Equal(t, "standalone", key)
+	require.Equal(t, map[string]string{"app": "x"}, labels)
+}
+
+func TestServiceGroups(t *testing.T) {
+	groups := newServiceGroups()
+
+	require.True(t, groups.add("a", clients.ContainerImage{Name: "myproj/web", Image: "nginx:1.25"}))
+	require.False(t, groups.add("b", clients.ContainerImage{Name: "myproj/web", Image: "nginx:1.25"}))
+	require.True(t, groups.add("c", clients.ContainerImage{Name: "myproj/web", Image: "nginx:1.26"}))
+
+	containerImage, ok := groups.get("myproj/web")
+	require.True(t, ok)
+	require.Equal(t, "nginx:1.26", containerImage.Image)
+
+	key, known, empty := groups.remove("a")
+	require.Equal(t, "myproj/web", key)
+	require.True(t, known)
+	require.False(t, empty)
+
+	_, _, empty = groups.remove("b")
+	require.False(t, empty)
+
+	_, _, empty = groups.remove("c")
+	require.True(t, empty)
+
+	_, ok = groups.get("myproj/web")
+	require.False(t, ok)
+
+	_, known, _ = groups.remove("unknown")
+	require.False(t, known)
+}
+
+func TestStripDigest(t *testing.T) {
+	require.Equal(t, "nginx:1.25", stripDigest("nginx:1.25@sha256:0123"))
+	require.Equal(t, "registry:5000/nginx@sha256:0123", stripDigest("registry:5000/nginx@sha256:0123"))
+	require.Equal(t, "nginx:1.25", stripDigest("nginx:1.25"))
+}
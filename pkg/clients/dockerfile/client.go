@@ -0,0 +1,40 @@
+package dockerfile
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/files"
+)
+
+type Config struct {
+	// Paths to Dockerfiles or directories containing them
+	Paths []string
+}
+
+// ContainerClient reports the base images of Dockerfiles once and closes the channel afterwards.
+type ContainerClient struct {
+	Config Config
+
+	logger *slog.Logger
+}
+
+func NewContainerClient(config Config, logger *slog.Logger) (*ContainerClient, error) {
+	return &ContainerClient{
+		Config: config,
+		logger: logger,
+	}, nil
+}
+
+func (c *ContainerClient) Listener(ctx context.Context) (<-chan clients.ContainerImage, error) {
+	return files.Listener(ctx, c.Config.Paths, isDockerfile, Parse)
+}
+
+func isDockerfile(name string) bool {
+	lowerName := strings.ToLower(name)
+
+	return lowerName == "dockerfile" || lowerName == "containerfile" ||
+		strings.HasPrefix(lowerName, "dockerfile.") || strings.HasSuffix(lowerName, ".dockerfile")
+}
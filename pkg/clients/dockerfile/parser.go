@@ -0,0 +1,278 @@
+package dockerfile
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/files"
+)
+
+var escapeDirective = regexp.MustCompile(`(?i)^#\s*escape\s*=\s*([\\` + "`" + `])\s*$`)
+
+// instruction is a logical Dockerfile instruction, with line continuations joined.
+type instruction struct {
+	text     string
+	segments []segment
+}
+
+// segment maps the start of a physical line to its offset in the joined instruction text.
+type segment struct {
+	offset int
+	line   int
+}
+
+type token struct {
+	value  string
+	offset int
+}
+
+// argDefault is the default value of a global ARG and where it is written.
+type argDefault struct {
+	value  string
+	line   int
+	column int
+}
+
+// Parse extracts the base images of all stages from a Dockerfile. Global ARGs are substituted into FROM lines,
+// references to earlier stages and scratch are skipped, as are images depending on ARGs without a default. If the
+// whole tag is an ARG, like nginx:${NGINX_VERSION}, the image is located at the default of the ARG, so updates
+// change it there.
+func Parse(file string, content []byte) ([]clients.ContainerImage, error) {
+	instructions := splitInstructions(string(content))
+
+	args := map[string]argDefault{}
+	stageNames := map[string]struct{}{}
+	stage := -1
+
+	var containerImages []clients.ContainerImage
+
+	for _, instruction := range instructions {
+		tokens := tokenize(instruction.text)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(tokens[0].value) {
+		case "ARG":
+			// ARGs after the first FROM belong to a stage and can't be used in FROM lines
+			if stage >= 0 {
+				continue
+			}
+
+			for _, argument := range tokens[1:] {
+				name, value, hasDefault := strings.Cut(argument.value, "=")
+				if !hasDefault {
+					continue
+				}
+
+				valueOffset := argument.offset + len(name) + 1
+
+				args[name] = argDefault{
+					value:  strings.Trim(value, `"'`),
+					line:   instruction.line(valueOffset),
+					column: instruction.column(valueOffset),
+				}
+			}
+		case "FROM":
+			stage++
+
+			imageToken, stageName := parseFrom(tokens[1:])
+			if stageName != "" {
+				stageNames[strings.ToLower(stageName)] = struct{}{}
+			}
+
+			if imageToken.value == "" {
+				return nil, fmt.Errorf("%s: FROM without image on line %d", file, instruction.line(imageToken.offset))
+			}
+
+			image, complete := files.Expand(imageToken.value, func(name string) (string, bool) {
+				arg, ok := args[name]
+
+				return arg.value, ok
+			})
+			if !complete {
+				continue
+			}
+
+			if _, isStage := stageNames[strings.ToLower(image)]; isStage || strings.EqualFold(image, "scratch") {
+				continue
+			}
+
+			path := fmt.Sprintf("stages[%d]", stage)
+
+			metadata := map[string]interface{}{
+				clients.MetadataFile:   file,
+				clients.MetadataLine:   instruction.line(imageToken.offset),
+				clients.MetadataColumn: instruction.column(imageToken.offset),
+			}
+
+			if imageToken.value != image {
+				metadata[clients.MetadataReference] = imageToken.value
+
+				if arg, ok := args[tagVariable(imageToken.value)]; ok {
+					metadata[clients.MetadataLine] = arg.line
+					metadata[clients.MetadataColumn] = arg.column
+					metadata[clients.MetadataReference] = arg.value
+				}
+			}
+
+			containerImages = append(containerImages, clients.ContainerImage{
+				Action:   clients.ContainerImageAdded,
+				Name:     file + ":" + path,
+				Metadata: metadata,
+				Labels: map[string]string{
+					"file": file,
+					"path": path,
+				},
+				Annotations: map[string]string{},
+				Image:       image,
+			})
+		}
+	}
+
+	return containerImages, nil
+}
+
+// tagVariable returns the name of the variable making up the whole tag of a reference like nginx:${NGINX_VERSION} or
+// nginx:$NGINX_VERSION, empty if the tag is anything else.
+func tagVariable(reference string) string {
+	separator := strings.LastIndex(reference, ":")
+	if separator < 0 || separator < strings.LastIndex(reference, "/") {
+		return ""
+	}
+
+	tag := reference[separator+1:]
+
+	if name, ok := strings.CutPrefix(tag, "${"); ok {
+		name, ok = strings.CutSuffix(name, "}")
+		if ok && !strings.ContainsAny(name, "${}:-+") {
+			return name
+		}
+
+		return ""
+	}
+
+	if name, ok := strings.CutPrefix(tag, "$"); ok && name != "" && !strings.ContainsAny(name, "${}:-+.") {
+		return name
+	}
+
+	return ""
+}
+
+// parseFrom returns the image and optional stage name of the arguments of a FROM instruction.
+func parseFrom(arguments []token) (token, string) {
+	for len(arguments) > 0 && strings.HasPrefix(arguments[0].value, "--") {
+		arguments = arguments[1:]
+	}
+
+	if len(arguments) == 0 {
+		return token{}, ""
+	}
+
+	if len(arguments) >= 3 && strings.EqualFold(arguments[1].value, "AS") {
+		return arguments[0], arguments[2].value
+	}
+
+	return arguments[0], ""
+}
+
+func splitInstructions(content string) []instruction {
+	escape := byte('\\')
+	directivesAllowed := true
+
+	var instructions []instruction
+
+	var current *instruction
+
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if current == nil && directivesAllowed {
+			if match := escapeDirective.FindStringSubmatch(trimmed); match != nil {
+				escape = match[1][0]
+
+				continue
+			}
+
+			directivesAllowed = false
+		}
+
+		// Empty lines and comments are removed, even inside of continued instructions
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+
+		if current == nil {
+			current = &instruction{}
+		}
+
+		part := strings.TrimRight(line, " \t")
+		continued := part[len(part)-1] == escape
+
+		if continued {
+			part = part[:len(part)-1]
+		}
+
+		current.segments = append(current.segments, segment{offset: len(current.text), line: i + 1})
+		current.text += part
+
+		if !continued {
+			instructions = append(instructions, *current)
+			current = nil
+		}
+	}
+
+	if current != nil {
+		instructions = append(instructions, *current)
+	}
+
+	return instructions
+}
+
+func tokenize(text string) []token {
+	var tokens []token
+
+	start := -1
+
+	for i := 0; i <= len(text); i++ {
+		if i == len(text) || text[i] == ' ' || text[i] == '\t' {
+			if start >= 0 {
+				tokens = append(tokens, token{value: text[start:i], offset: start})
+				start = -1
+			}
+
+			continue
+		}
+
+		if start < 0 {
+			start = i
+		}
+	}
+
+	return tokens
+}
+
+func (i instruction) segment(offset int) segment {
+	result := i.segments[0]
+
+	for _, segment := range i.segments {
+		if segment.offset > offset {
+			break
+		}
+
+		result = segment
+	}
+
+	return result
+}
+
+func (i instruction) line(offset int) int {
+	return i.segment(offset).line
+}
+
+func (i instruction) column(offset int) int {
+	return offset - i.segment(offset).offset + 1
+}
@@ -0,0 +1,48 @@
+package dockerfile_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/dockerfile"
+)
+
+func TestParse(t *testing.T) {
+	content, err := os.ReadFile("testdata/Dockerfile")
+	require.NoError(t, err)
+
+	containerImages, err := dockerfile.Parse("Dockerfile", content)
+	require.NoError(t, err)
+	require.Len(t, containerImages, 2)
+
+	builder := containerImages[0]
+	require.Equal(t, "golang:1.22.2", builder.Image)
+	require.Equal(t, "Dockerfile:stages[0]", builder.Name)
+	require.Equal(t, map[string]string{"file": "Dockerfile", "path": "stages[0]"}, builder.Labels)
+
+	// The tag is the default of the ARG, so the image is located there
+	require.Equal(t, 2, builder.Metadata[clients.MetadataLine])
+	require.Equal(t, 16, builder.Metadata[clients.MetadataColumn])
+	require.Equal(t, "1.22.2", builder.Metadata[clients.MetadataReference])
+
+	continued := containerImages[1]
+	require.Equal(t, "alpine:3.19.1", continued.Image)
+	require.Equal(t, "stages[2]", continued.Labels["path"])
+	require.Equal(t, 12, continued.Metadata[clients.MetadataLine])
+	require.Equal(t, 5, continued.Metadata[clients.MetadataColumn])
+	require.NotContains(t, continued.Metadata, clients.MetadataReference)
+}
+
+func TestParse_EscapeDirective(t *testing.T) {
+	content := []byte("# escape=`\nFROM `\n  mcr.microsoft.com/windows/servercore:ltsc2022\n")
+
+	containerImages, err := dockerfile.Parse("Dockerfile", content)
+	require.NoError(t, err)
+	require.Len(t, containerImages, 1)
+	require.Equal(t, "mcr.microsoft.com/windows/servercore:ltsc2022", containerImages[0].Image)
+	require.Equal(t, 3, containerImages[0].Metadata[clients.MetadataLine])
+	require.Equal(t, 3, containerImages[0].Metadata[clients.MetadataColumn])
+}
@@ -0,0 +1,103 @@
+package files
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Stdin is the path that refers to standard input instead of a file
+const Stdin = "-"
+
+type File struct {
+	// Name of the file as given on the command line or found while walking a directory
+	Name    string
+	Content []byte
+}
+
+// Read reads the given files. Directories are walked recursively. Only files accepted by match are read, so
+// several sources can share the same paths. match is called with the base name of a file, or Stdin.
+func Read(paths []string, match func(name string) bool) ([]File, error) {
+	var result []File
+
+	for _, path := range paths {
+		if path == Stdin {
+			if !match(Stdin) {
+				continue
+			}
+
+			content, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, File{Name: Stdin, Content: content})
+
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			if !match(filepath.Base(path)) {
+				continue
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, File{Name: path, Content: content})
+
+			continue
+		}
+
+		var names []string
+
+		err = filepath.WalkDir(path, func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if entry.IsDir() {
+				if name != path && isHidden(entry.Name()) {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if match(entry.Name()) {
+				names = append(names, name)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			content, err := os.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, File{Name: name, Content: content})
+		}
+	}
+
+	return result, nil
+}
+
+func isHidden(name string) bool {
+	return len(name) > 1 && name[0] == '.'
+}
@@ -0,0 +1,48 @@
+package files
+
+import (
+	"context"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+)
+
+// Listener reads and parses all matching files up front, then reports every container image once and closes
+// the channel. Parse errors are returned directly, so a broken file fails the scan instead of being skipped.
+func Listener(
+	ctx context.Context,
+	paths []string,
+	match func(name string) bool,
+	parse func(file string, content []byte) ([]clients.ContainerImage, error),
+) (<-chan clients.ContainerImage, error) {
+	files, err := Read(paths, match)
+	if err != nil {
+		return nil, err
+	}
+
+	var containerImages []clients.ContainerImage
+
+	for _, file := range files {
+		fileContainerImages, err := parse(file.Name, file.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		containerImages = append(containerImages, fileContainerImages...)
+	}
+
+	containerImageChannel := make(chan clients.ContainerImage)
+
+	go func() {
+		defer close(containerImageChannel)
+
+		for _, containerImage := range append(containerImages, clients.ContainerImage{Action: clients.ContainerImagesListed}) {
+			select {
+			case containerImageChannel <- containerImage:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return containerImageChannel, nil
+}
@@ -0,0 +1,112 @@
+package files
+
+import (
+	"strings"
+)
+
+// Expand substitutes shell-style variables as used in Dockerfiles and Compose files: $NAME, ${NAME} and the
+// ${NAME:-default}, ${NAME-default}, ${NAME:+alternative} and ${NAME+alternative} forms. $$ is a literal $.
+// The boolean result is false if a variable without default value could not be resolved.
+func Expand(value string, lookup func(name string) (string, bool)) (string, bool) {
+	var builder strings.Builder
+
+	complete := true
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			builder.WriteByte(value[i])
+
+			continue
+		}
+
+		next := value[i+1]
+
+		switch {
+		case next == '$':
+			builder.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.IndexByte(value[i+2:], '}')
+			if end < 0 {
+				builder.WriteString(value[i:])
+
+				return builder.String(), false
+			}
+
+			expanded, ok := expandExpression(value[i+2:i+2+end], lookup)
+			complete = complete && ok
+
+			builder.WriteString(expanded)
+			i += 2 + end
+		case isNameCharacter(next, true):
+			end := i + 1
+			for end < len(value) && isNameCharacter(value[end], false) {
+				end++
+			}
+
+			expanded, ok := lookup(value[i+1 : end])
+			complete = complete && ok
+
+			builder.WriteString(expanded)
+			i = end - 1
+		default:
+			builder.WriteByte('$')
+		}
+	}
+
+	return builder.String(), complete
+}
+
+func expandExpression(expression string, lookup func(name string) (string, bool)) (string, bool) {
+	nameEnd := 0
+	for nameEnd < len(expression) && isNameCharacter(expression[nameEnd], nameEnd == 0) {
+		nameEnd++
+	}
+
+	name, operator := expression[:nameEnd], expression[nameEnd:]
+	value, set := lookup(name)
+
+	switch {
+	case operator == "":
+		return value, set
+	case strings.HasPrefix(operator, ":-"):
+		if !set || value == "" {
+			return Expand(operator[2:], lookup)
+		}
+	case strings.HasPrefix(operator, "-"):
+		if !set {
+			return Expand(operator[1:], lookup)
+		}
+	case strings.HasPrefix(operator, ":+"):
+		if set && value != "" {
+			return Expand(operator[2:], lookup)
+		}
+
+		return "", true
+	case strings.HasPrefix(operator, "+"):
+		if set {
+			return Expand(operator[1:], lookup)
+		}
+
+		return "", true
+	case strings.HasPrefix(operator, ":?"):
+		return value, set && value != ""
+	case strings.HasPrefix(operator, "?"):
+		return value, set
+	default:
+		return "", false
+	}
+
+	return value, true
+}
+
+func isNameCharacter(character byte, first bool) bool {
+	switch {
+	case character == '_', character >= 'a' && character <= 'z', character >= 'A' && character <= 'Z':
+		return true
+	case character >= '0' && character <= '9':
+		return !first
+	}
+
+	return false
+}
@@ -9,6 +9,7 @@ import (
 
 	coreV1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -17,6 +18,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/metrics"
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
 )
 
@@ -30,6 +32,9 @@ type ConnectionConfig struct {
 	InClusterConfig        bool
 	InformerResyncInterval time.Duration
 	ImageCheckInterval     time.Duration
+
+	// Once reports every pod a single time after the informer cache is synced and closes the channel afterwards
+	Once bool
 }
 
 type ContainerClient struct {
@@ -43,23 +48,28 @@ type ContainerClient struct {
 	logger *slog.Logger
 
 	containerCache map[string][]string
+
+	// ownerCache maps ReplicaSets and Jobs to their owning workload, podOwners maps pod keys to the entry they use
+	ownerCache map[types.UID]*cachedOwner
+	podOwners  map[string]types.UID
+}
+
+// RestConfig returns the connection configuration for the cluster, either from the service account of the pod or
+// from the local kubeconfig.
+func RestConfig(inClusterConfig bool) (*rest.Config, error) {
+	if inClusterConfig {
+		return rest.InClusterConfig()
+	}
+
+	pathOptions := clientcmd.NewDefaultPathOptions()
+
+	return clientcmd.BuildConfigFromKubeconfigGetter("", pathOptions.GetStartingConfig)
 }
 
 func NewContainerClient(config ConnectionConfig, logger *slog.Logger) (*ContainerClient, error) {
-	var k8sConfig *rest.Config
-	if config.InClusterConfig {
-		var err error
-		k8sConfig, err = rest.InClusterConfig()
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		var err error
-		pathOptions := clientcmd.NewDefaultPathOptions()
-		k8sConfig, err = clientcmd.BuildConfigFromKubeconfigGetter("", pathOptions.GetStartingConfig)
-		if err != nil {
-			return nil, err
-		}
+	k8sConfig, err := RestConfig(config.InClusterConfig)
+	if err != nil {
+		return nil, err
 	}
 
 	clientset, err := kubernetes.NewForConfig(k8sConfig)
@@ -70,7 +80,10 @@ func NewContainerClient(config ConnectionConfig, logger *slog.Logger) (*Containe
 	factory := informers.NewSharedInformerFactory(clientset, config.InformerResyncInterval)
 	informer := factory.Core().V1().Pods().Informer()
 
-	queue := workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Second, time.Minute))
+	queue := workqueue.NewRateLimitingQueueWithConfig(
+		workqueue.NewItemExponentialFailureRateLimiter(time.Second, time.Minute),
+		workqueue.RateLimitingQueueConfig{Name: "pods"},
+	)
 	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			key, err := cache.MetaNamespaceKeyFunc(obj)
@@ -94,10 +107,14 @@ func NewContainerClient(config ConnectionConfig, logger *slog.Logger) (*Containe
 		workqueue:      queue,
 		logger:         logger,
 		containerCache: map[string][]string{},
+		ownerCache:     map[types.UID]*cachedOwner{},
+		podOwners:      map[string]types.UID{},
 	}, nil
 }
 
 func (c *ContainerClient) Listener(ctx context.Context) (<-chan clients.ContainerImage, error) {
+	metrics.AddInformer("pods", c.informer.HasSynced)
+
 	go c.informer.Run(ctx.Done())
 
 	containerImageChannel := make(chan clients.ContainerImage)
@@ -106,13 +123,65 @@ func (c *ContainerClient) Listener(ctx context.Context) (<-chan clients.Containe
 		return nil, ErrInformerCacheSync
 	}
 
+	if c.Config.Once {
+		go func() {
+			defer close(containerImageChannel)
+
+			for _, obj := range c.informer.GetIndexer().List() {
+				pod, ok := obj.(*coreV1.Pod)
+				if !ok {
+					continue
+				}
+
+				key, err := cache.MetaNamespaceKeyFunc(pod)
+				if err != nil {
+					continue
+				}
+
+				for _, containerImage := range c.podContainerImages(key, pod) {
+					select {
+					case containerImageChannel <- containerImage:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+
+			select {
+			case containerImageChannel <- clients.ContainerImage{Action: clients.ContainerImagesListed}:
+			case <-ctx.Done():
+			}
+		}()
+
+		return containerImageChannel, nil
+	}
+
+	// The pods of the synced cache are queued with a random delay, the listing is complete once all of them were
+	// processed
+	listing := map[string]struct{}{}
+	for _, key := range c.informer.GetIndexer().ListKeys() {
+		listing[key] = struct{}{}
+	}
+
 	go func() {
+		if len(listing) == 0 {
+			containerImageChannel <- clients.ContainerImage{Action: clients.ContainerImagesListed}
+		}
+
 		for key, quit := c.workqueue.Get(); !quit; key, quit = c.workqueue.Get() {
 			containerImages := c.processWorkqueue(key.(string))
 
 			for _, containerImage := range containerImages {
 				containerImageChannel <- containerImage
 			}
+
+			if _, ok := listing[key.(string)]; ok {
+				delete(listing, key.(string))
+
+				if len(listing) == 0 {
+					containerImageChannel <- clients.ContainerImage{Action: clients.ContainerImagesListed}
+				}
+			}
 		}
 	}()
 
@@ -134,6 +203,8 @@ func (c *ContainerClient) processWorkqueue(key string) []clients.ContainerImage
 	}
 
 	if !exists {
+		c.forgetOwner(key)
+
 		containers, ok := c.containerCache[key]
 		if !ok {
 			return nil
@@ -158,12 +229,35 @@ func (c *ContainerClient) processWorkqueue(key string) []clients.ContainerImage
 		return nil
 	}
 
+	containerImages := c.podContainerImages(key, pod)
+
+	containers := make([]string, 0, len(containerImages))
+	for _, containerImage := range containerImages {
+		containers = append(containers, containerImage.Name)
+	}
+
+	c.containerCache[key] = containers
+
+	c.workqueue.AddAfter(key, clients.RecheckDelay(c.Config.ImageCheckInterval))
+
+	return containerImages
+}
+
+func (c *ContainerClient) podContainerImages(key string, pod *coreV1.Pod) []clients.ContainerImage {
 	images := map[string]string{}
 
 	for _, container := range pod.Spec.Containers {
 		images[container.Name] = container.Image
 	}
 
+	digests := map[string]string{}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if digest := clients.DigestOf(status.ImageID); digest != "" {
+			digests[status.Name] = digest
+		}
+	}
+
 	var imagePullSecrets []*coreV1.Secret
 	if podServiceAccountName := pod.Spec.ServiceAccountName; podServiceAccountName != "" {
 		podServiceAccount, err := c.clientset.CoreV1().ServiceAccounts(pod.Namespace).Get(context.Background(), pod.Spec.ServiceAccountName, metav1.GetOptions{})
@@ -194,25 +288,31 @@ func (c *ContainerClient) processWorkqueue(key string) []clients.ContainerImage
 	}
 
 	keychain := tags.RegistryCredentialsFromSecrets(imagePullSecrets)
+	workload := c.resolveWorkload(context.Background(), key, pod)
 
 	containerImages := make([]clients.ContainerImage, 0, len(images))
 
 	for name, image := range images {
+		metadata := map[string]interface{}{
+			clients.MetadataDockerKeychain: keychain,
+			clients.MetadataNamespace:      pod.Namespace,
+			clients.MetadataWorkload:       workload,
+			clients.MetadataContainer:      name,
+		}
+
+		if digest, ok := digests[name]; ok {
+			metadata[clients.MetadataDigest] = digest
+		}
+
 		containerImages = append(containerImages, clients.ContainerImage{
-			Action: clients.ContainerImageAdded,
-			Name:   key + "/" + name,
-			Metadata: map[string]interface{}{
-				"DockerKeychain": keychain,
-			},
+			Action:      clients.ContainerImageAdded,
+			Name:        key + "/" + name,
+			Metadata:    metadata,
 			Labels:      pod.Labels,
 			Annotations: pod.Annotations,
 			Image:       image,
 		})
 	}
 
-	delay := time.Duration(c.Config.ImageCheckInterval.Nanoseconds() + rand.Int63n(c.Config.ImageCheckInterval.Nanoseconds()/2))
-
-	c.workqueue.AddAfter(key, delay)
-
 	return containerImages
 }
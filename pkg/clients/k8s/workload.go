@@ -0,0 +1,153 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+
+	coreV1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Workload is the object owning a pod, e.g. the Deployment of a ReplicaSet's pods. Pods without a known owner are
+// their own workload.
+type Workload struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	UID        types.UID
+}
+
+// ObjectReference returns a reference to the workload, e.g. for recording events.
+func (w Workload) ObjectReference() *coreV1.ObjectReference {
+	return &coreV1.ObjectReference{
+		APIVersion: w.APIVersion,
+		Kind:       w.Kind,
+		Namespace:  w.Namespace,
+		Name:       w.Name,
+		UID:        w.UID,
+	}
+}
+
+// cachedOwner is the owning workload of a ReplicaSet or Job, nil if it has none, and the pods referencing it.
+type cachedOwner struct {
+	parent *Workload
+	pods   map[string]struct{}
+}
+
+// resolveWorkload follows the controller references of a pod up to the workload a user manages: ReplicaSets to
+// their Deployment and Jobs to their CronJob. Owners of intermediate objects are cached by UID until their last
+// pod is deleted.
+func (c *ContainerClient) resolveWorkload(ctx context.Context, key string, pod *coreV1.Pod) Workload {
+	workload := Workload{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Namespace:  pod.Namespace,
+		Name:       pod.Name,
+		UID:        pod.UID,
+	}
+
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return workload
+	}
+
+	workload = Workload{
+		APIVersion: owner.APIVersion,
+		Kind:       owner.Kind,
+		Namespace:  pod.Namespace,
+		Name:       owner.Name,
+		UID:        owner.UID,
+	}
+
+	if owner.Kind != "ReplicaSet" && owner.Kind != "Job" {
+		return workload
+	}
+
+	cached, ok := c.ownerCache[owner.UID]
+	if !ok {
+		parentOwner, err := c.getControllerOf(ctx, pod.Namespace, owner.Kind, owner.Name)
+		if err != nil {
+			c.logger.Warn("error resolving workload owner", "kind", owner.Kind, "namespace", pod.Namespace, "name", owner.Name, "error", err)
+
+			return workload
+		}
+
+		cached = &cachedOwner{pods: map[string]struct{}{}}
+
+		if parentOwner != nil {
+			cached.parent = &Workload{
+				APIVersion: parentOwner.APIVersion,
+				Kind:       parentOwner.Kind,
+				Namespace:  pod.Namespace,
+				Name:       parentOwner.Name,
+				UID:        parentOwner.UID,
+			}
+		}
+
+		c.ownerCache[owner.UID] = cached
+	}
+
+	if previous, ok := c.podOwners[key]; ok && previous != owner.UID {
+		c.forgetOwner(key)
+	}
+
+	cached.pods[key] = struct{}{}
+	c.podOwners[key] = owner.UID
+
+	if cached.parent == nil {
+		return workload
+	}
+
+	return *cached.parent
+}
+
+// forgetOwner removes a deleted pod from the owner cache and evicts its ReplicaSet or Job once no pod references it
+// anymore.
+func (c *ContainerClient) forgetOwner(key string) {
+	uid, ok := c.podOwners[key]
+	if !ok {
+		return
+	}
+
+	delete(c.podOwners, key)
+
+	cached, ok := c.ownerCache[uid]
+	if !ok {
+		return
+	}
+
+	delete(cached.pods, key)
+
+	if len(cached.pods) == 0 {
+		delete(c.ownerCache, uid)
+	}
+}
+
+func (c *ContainerClient) getControllerOf(ctx context.Context, namespace, kind, name string) (*metav1.OwnerReference, error) {
+	var (
+		object metav1.Object
+		err    error
+	)
+
+	switch kind {
+	case "ReplicaSet":
+		object, err = c.clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Job":
+		object, err = c.clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	default:
+		return nil, errors.New("unsupported owner kind " + kind)
+	}
+
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return metav1.GetControllerOf(object), nil
+}
@@ -0,0 +1,64 @@
+package k8s
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestResolveWorkload_Eviction(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write([]byte(`{"apiVersion":"batch/v1","kind":"Job","metadata":{"name":"backup-1","namespace":"ops",` +
+			`"ownerReferences":[{"apiVersion":"batch/v1","kind":"CronJob","name":"backup","uid":"cronjob","controller":true}]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	containerClient := &ContainerClient{
+		clientset:  clientset,
+		logger:     slog.Default(),
+		ownerCache: map[types.UID]*cachedOwner{},
+		podOwners:  map[string]types.UID{},
+	}
+
+	controller := true
+	pod := func(name string) *coreV1.Pod {
+		return &coreV1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ops",
+			Name:      name,
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "batch/v1", Kind: "Job", Name: "backup-1", UID: "job", Controller: &controller},
+			},
+		}}
+	}
+
+	workload := containerClient.resolveWorkload(context.Background(), "ops/backup-1-a", pod("backup-1-a"))
+	require.Equal(t, Workload{APIVersion: "batch/v1", Kind: "CronJob", Namespace: "ops", Name: "backup", UID: "cronjob"}, workload)
+
+	workload = containerClient.resolveWorkload(context.Background(), "ops/backup-1-b", pod("backup-1-b"))
+	require.Equal(t, "backup", workload.Name)
+	require.Equal(t, 1, requests)
+
+	containerClient.forgetOwner("ops/backup-1-a")
+	require.Len(t, containerClient.ownerCache, 1)
+
+	containerClient.forgetOwner("ops/backup-1-b")
+	require.Empty(t, containerClient.ownerCache)
+	require.Empty(t, containerClient.podOwners)
+}
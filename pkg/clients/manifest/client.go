@@ -0,0 +1,48 @@
+package manifest
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/files"
+)
+
+type Config struct {
+	// Paths to manifest files or directories containing them. "-" reads from stdin, e.g. for piping the output
+	// of helm template or kustomize build.
+	Paths []string
+}
+
+// ContainerClient reports the images of Kubernetes manifests on disk. Unlike the cluster sources it reports
+// every image once and closes the channel afterwards.
+type ContainerClient struct {
+	Config Config
+
+	logger *slog.Logger
+}
+
+func NewContainerClient(config Config, logger *slog.Logger) (*ContainerClient, error) {
+	return &ContainerClient{
+		Config: config,
+		logger: logger,
+	}, nil
+}
+
+func (c *ContainerClient) Listener(ctx context.Context) (<-chan clients.ContainerImage, error) {
+	return files.Listener(ctx, c.Config.Paths, isManifestFile, Parse)
+}
+
+func isManifestFile(name string) bool {
+	if name == files.Stdin {
+		return true
+	}
+
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+
+	return false
+}
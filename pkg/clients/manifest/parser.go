@@ -0,0 +1,177 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+)
+
+var containerListKeys = []string{"containers", "initContainers", "ephemeralContainers"}
+
+// Parse extracts all container images from a file containing one or more Kubernetes manifests. Every kind that
+// carries a pod template is supported, as well as List kinds.
+func Parse(file string, content []byte) ([]clients.ContainerImage, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	var containerImages []clients.ContainerImage
+
+	for document := 0; ; document++ {
+		var node yaml.Node
+
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		if node.Kind != yaml.DocumentNode || len(node.Content) == 0 {
+			continue
+		}
+
+		containerImages = append(containerImages, parseObject(file, fmt.Sprintf("[%d]", document), node.Content[0])...)
+	}
+
+	return containerImages, nil
+}
+
+func parseObject(file, path string, object *yaml.Node) []clients.ContainerImage {
+	if object.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	kind := scalar(lookup(object, "kind"))
+
+	if items := lookup(object, "items"); strings.HasSuffix(kind, "List") && items != nil && items.Kind == yaml.SequenceNode {
+		var containerImages []clients.ContainerImage
+
+		for i, item := range items.Content {
+			containerImages = append(containerImages, parseObject(file, fmt.Sprintf("%s.items[%d]", path, i), item)...)
+		}
+
+		return containerImages
+	}
+
+	var templatePath []string
+
+	switch kind {
+	case "":
+		return nil
+	case "Pod":
+		templatePath = nil
+	case "PodTemplate":
+		templatePath = []string{"template"}
+	case "CronJob":
+		templatePath = []string{"spec", "jobTemplate", "spec", "template"}
+	default:
+		templatePath = []string{"spec", "template"}
+	}
+
+	template := lookup(object, templatePath...)
+	if template == nil {
+		return nil
+	}
+
+	annotations := stringMap(lookup(object, "metadata", "annotations"))
+	for key, value := range stringMap(lookup(template, "metadata", "annotations")) {
+		annotations[key] = value
+	}
+
+	specPath := path
+	for _, key := range append(templatePath, "spec") {
+		specPath += "." + key
+	}
+
+	spec := lookup(template, "spec")
+	if spec == nil {
+		return nil
+	}
+
+	var containerImages []clients.ContainerImage
+
+	for _, listKey := range containerListKeys {
+		containers := lookup(spec, listKey)
+		if containers == nil || containers.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for i, container := range containers.Content {
+			image := lookup(container, "image")
+			if image == nil || image.Kind != yaml.ScalarNode || image.Value == "" {
+				continue
+			}
+
+			imagePath := fmt.Sprintf("%s.%s[%d].image", specPath, listKey, i)
+
+			containerImages = append(containerImages, clients.ContainerImage{
+				Action: clients.ContainerImageAdded,
+				Name:   file + ":" + imagePath,
+				Metadata: map[string]interface{}{
+					clients.MetadataFile:   file,
+					clients.MetadataLine:   image.Line,
+					clients.MetadataColumn: image.Column,
+				},
+				Labels: map[string]string{
+					"file": file,
+					"path": imagePath,
+				},
+				Annotations: annotations,
+				Image:       image.Value,
+			})
+		}
+	}
+
+	return containerImages
+}
+
+func lookup(node *yaml.Node, keys ...string) *yaml.Node {
+	for _, key := range keys {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		var value *yaml.Node
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				value = node.Content[i+1]
+
+				break
+			}
+		}
+
+		node = value
+	}
+
+	return node
+}
+
+func scalar(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+
+	return node.Value
+}
+
+func stringMap(node *yaml.Node) map[string]string {
+	result := map[string]string{}
+
+	if node == nil || node.Kind != yaml.MappingNode {
+		return result
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		result[node.Content[i].Value] = scalar(node.Content[i+1])
+	}
+
+	return result
+}
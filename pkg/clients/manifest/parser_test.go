@@ -0,0 +1,47 @@
+package manifest_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/manifest"
+)
+
+func TestParse(t *testing.T) {
+	content, err := os.ReadFile("testdata/workloads.yaml")
+	require.NoError(t, err)
+
+	containerImages, err := manifest.Parse("workloads.yaml", content)
+	require.NoError(t, err)
+	require.Len(t, containerImages, 4)
+
+	require.Equal(t, "ghcr.io/example/migrate:1.0.0", containerImages[2].Image)
+	require.Equal(t, "[0].spec.template.spec.initContainers[0].image", containerImages[2].Labels["path"])
+
+	web := containerImages[0]
+	require.Equal(t, "nginx:1.25.3", web.Image)
+	require.Equal(t, clients.ContainerImageAdded, web.Action)
+	require.Equal(t, "workloads.yaml:[0].spec.template.spec.containers[0].image", web.Name)
+	require.Equal(t, map[string]string{"file": "workloads.yaml", "path": "[0].spec.template.spec.containers[0].image"}, web.Labels)
+	require.Equal(t, "minor", web.Annotations["outdated-images.patrick246.de/pin-mode"])
+	require.Equal(t, 19, web.Metadata[clients.MetadataLine])
+	require.Equal(t, 18, web.Metadata[clients.MetadataColumn])
+
+	backup := containerImages[3]
+	require.Equal(t, "postgres:16.1", backup.Image)
+	require.Equal(t, "[2].spec.jobTemplate.spec.template.spec.containers[0].image", backup.Labels["path"])
+}
+
+func TestParse_List(t *testing.T) {
+	content, err := os.ReadFile("testdata/list.json")
+	require.NoError(t, err)
+
+	containerImages, err := manifest.Parse("list.json", content)
+	require.NoError(t, err)
+	require.Len(t, containerImages, 1)
+	require.Equal(t, "redis:7.2.4", containerImages[0].Image)
+	require.Equal(t, "[0].items[0].spec.containers[0].image", containerImages[0].Labels["path"])
+}
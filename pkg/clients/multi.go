@@ -0,0 +1,98 @@
+package clients
+
+import (
+	"context"
+	"sync"
+)
+
+type Source interface {
+	Listener(ctx context.Context) (<-chan ContainerImage, error)
+}
+
+// MultiClient merges the container images of several sources. The merged channel is closed once every source
+// has closed its channel, which only happens if all of them are one-shot sources like file scanners. The listing is
+// marked as complete once every source marked its own listing as complete or closed its channel.
+type MultiClient struct {
+	sources []Source
+}
+
+func NewMultiClient(sources ...Source) *MultiClient {
+	return &MultiClient{
+		sources: sources,
+	}
+}
+
+func (m *MultiClient) Listener(ctx context.Context) (<-chan ContainerImage, error) {
+	channels := make([]<-chan ContainerImage, 0, len(m.sources))
+
+	for _, source := range m.sources {
+		channel, err := source.Listener(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		channels = append(channels, channel)
+	}
+
+	merged := make(chan ContainerImage)
+	wg := sync.WaitGroup{}
+
+	var (
+		mutex     sync.Mutex
+		remaining = len(channels)
+	)
+
+	// listed counts a source as listed and returns whether it was the last one
+	listed := func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		remaining--
+
+		return remaining == 0
+	}
+
+	for _, channel := range channels {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sourceListed := false
+
+			for containerImage := range channel {
+				if containerImage.Action == ContainerImagesListed {
+					if sourceListed {
+						continue
+					}
+
+					sourceListed = true
+
+					if !listed() {
+						continue
+					}
+				}
+
+				select {
+				case merged <- containerImage:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if !sourceListed && listed() {
+				select {
+				case merged <- ContainerImage{Action: ContainerImagesListed}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	return merged, nil
+}
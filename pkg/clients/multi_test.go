@@ -0,0 +1,41 @@
+package clients_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+)
+
+type channelSource chan clients.ContainerImage
+
+func (c channelSource) Listener(context.Context) (<-chan clients.ContainerImage, error) {
+	return c, nil
+}
+
+func TestMultiClient_Listed(t *testing.T) {
+	watching := make(channelSource)
+	oneShot := make(channelSource, 1)
+
+	oneShot <- clients.ContainerImage{Action: clients.ContainerImageAdded, Name: "Dockerfile"}
+	close(oneShot)
+
+	merged, err := clients.NewMultiClient(watching, oneShot).Listener(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, "Dockerfile", (<-merged).Name)
+
+	watching <- clients.ContainerImage{Action: clients.ContainerImageAdded, Name: "default/web/app"}
+	require.Equal(t, "default/web/app", (<-merged).Name)
+
+	// The closed one-shot source counts as listed, the merged listing waits for the watching source
+	watching <- clients.ContainerImage{Action: clients.ContainerImagesListed}
+	require.Equal(t, clients.ContainerImagesListed, (<-merged).Action)
+
+	close(watching)
+
+	_, ok := <-merged
+	require.False(t, ok)
+}
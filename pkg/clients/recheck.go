@@ -0,0 +1,16 @@
+package clients
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RecheckDelay returns when a container image should be checked again: the check interval plus up to half of
+// it as jitter, so images discovered together don't all hit the registry at the same time.
+func RecheckDelay(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+
+	return time.Duration(interval.Nanoseconds() + rand.Int63n(interval.Nanoseconds()/2+1))
+}
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/util/homedir"
+)
+
+// Config is the structure of the configuration file. Every setting has a default, so an empty file is valid.
+type Config struct {
+	Sources    Sources    `yaml:"sources" toml:"sources"`
+	Registries []Registry `yaml:"registries" toml:"registries"`
+	Auth       Auth       `yaml:"auth" toml:"auth"`
+	Rewrites   []Rewrite  `yaml:"rewrites" toml:"rewrites"`
+	Policy     Policy     `yaml:"policy" toml:"policy"`
+	Evaluator  Evaluator  `yaml:"evaluator" toml:"evaluator"`
+	Output     Output     `yaml:"output" toml:"output"`
+
+	Notifications Notifications `yaml:"notifications" toml:"notifications"`
+	State         State         `yaml:"state" toml:"state"`
+	Telemetry     Telemetry     `yaml:"telemetry" toml:"telemetry"`
+}
+
+type Sources struct {
+	// Providers to read container images from: kubernetes, docker, manifest, dockerfile, compose
+	Providers []string `yaml:"providers" toml:"providers"`
+
+	// ImageCheckInterval controls how often the images of running containers are checked again
+	ImageCheckInterval Duration `yaml:"imageCheckInterval" toml:"imageCheckInterval"`
+
+	Kubernetes Kubernetes `yaml:"kubernetes" toml:"kubernetes"`
+	Docker     Docker     `yaml:"docker" toml:"docker"`
+
+	// Paths scanned by the manifest, dockerfile and compose providers
+	Paths []string `yaml:"paths" toml:"paths"`
+}
+
+type Kubernetes struct {
+	InCluster              bool     `yaml:"inCluster" toml:"inCluster"`
+	InformerResyncInterval Duration `yaml:"informerResyncInterval" toml:"informerResyncInterval"`
+
+	// Policies watches ImageUpdatePolicy resources if the CRD is installed
+	Policies bool `yaml:"policies" toml:"policies"`
+}
+
+type Docker struct {
+	SwarmServices bool `yaml:"swarmServices" toml:"swarmServices"`
+}
+
+// Registry holds connection settings of a single registry.
+type Registry struct {
+	// Host of the registry, e.g. registry.example.com:5000
+	Host string `yaml:"host" toml:"host"`
+
+	// Insecure connects to the registry via plain HTTP
+	Insecure bool `yaml:"insecure" toml:"insecure"`
+}
+
+type Auth struct {
+	// CredentialsFile is a Docker config.json with registry credentials
+	CredentialsFile string `yaml:"credentialsFile" toml:"credentialsFile"`
+
+	// Registries contains credentials per registry host, in addition to the credentials file
+	Registries map[string]Credentials `yaml:"registries" toml:"registries"`
+}
+
+type Credentials struct {
+	Username      string `yaml:"username" toml:"username"`
+	Password      string `yaml:"password" toml:"password"`
+	IdentityToken string `yaml:"identityToken" toml:"identityToken"`
+}
+
+// Rewrite replaces a repository prefix before the tags of an image are listed, e.g. to query a mirror.
+type Rewrite struct {
+	From string `yaml:"from" toml:"from"`
+	To   string `yaml:"to" toml:"to"`
+}
+
+// Policy is the default update policy, annotations override it per container.
+type Policy struct {
+	PinMode    string `yaml:"pinMode" toml:"pinMode"`
+	Constraint string `yaml:"constraint" toml:"constraint"`
+}
+
+type Evaluator struct {
+	// Workers is the number of images evaluated in parallel
+	Workers int `yaml:"workers" toml:"workers"`
+
+	// Timeout for evaluating a single image
+	Timeout Duration `yaml:"timeout" toml:"timeout"`
+}
+
+type Output struct {
+	ListenAddr string `yaml:"listenAddr" toml:"listenAddr"`
+	LogLevel   string `yaml:"logLevel" toml:"logLevel"`
+	LogFormat  string `yaml:"logFormat" toml:"logFormat"`
+
+	// Reports maintains an OutdatedImageReport per workload when the kubernetes provider is used
+	Reports bool `yaml:"reports" toml:"reports"`
+
+	// Events records a Warning event on a workload when one of its images falls further behind
+	Events bool `yaml:"events" toml:"events"`
+
+	// MetricLabels are the labels of the container sources, e.g. pod labels, copied to the image metrics
+	MetricLabels []string `yaml:"metricLabels" toml:"metricLabels"`
+
+	// TLS serves the metrics, API and dashboard via HTTPS
+	TLS TLS `yaml:"tls" toml:"tls"`
+
+	// Access protects the endpoints of the metrics server
+	Access Access `yaml:"access" toml:"access"`
+}
+
+type TLS struct {
+	// CertFile and KeyFile enable HTTPS, they are read again when they change
+	CertFile string `yaml:"certFile" toml:"certFile"`
+	KeyFile  string `yaml:"keyFile" toml:"keyFile"`
+
+	// ClientCAFile verifies client certificates, their common name and organizations are the user and groups
+	ClientCAFile string `yaml:"clientCAFile" toml:"clientCAFile"`
+}
+
+// Access is who may use each group of endpoints: none allows everyone, authenticated requires a client certificate
+// or a bearer token accepted by a TokenReview, rbac also requires a SubjectAccessReview of the request path.
+type Access struct {
+	Metrics   string `yaml:"metrics" toml:"metrics"`
+	API       string `yaml:"api" toml:"api"`
+	Dashboard string `yaml:"dashboard" toml:"dashboard"`
+}
+
+type Notifications struct {
+	// Interval in which changes are collected into one notification per receiver
+	Interval  Duration   `yaml:"interval" toml:"interval"`
+	Receivers []Receiver `yaml:"receivers" toml:"receivers"`
+}
+
+// Receiver is a webhook notified when images become outdated, fall further behind or are up to date again.
+type Receiver struct {
+	Name    string            `yaml:"name" toml:"name"`
+	URL     string            `yaml:"url" toml:"url"`
+	Headers map[string]string `yaml:"headers" toml:"headers"`
+
+	// Format of the request body: json, slack or teams. Template replaces it with a custom text/template.
+	Format   string `yaml:"format" toml:"format"`
+	Template string `yaml:"template" toml:"template"`
+
+	// Namespaces routes only images of these namespaces to the receiver, empty routes every image
+	Namespaces []string `yaml:"namespaces" toml:"namespaces"`
+
+	// MinSeverity is the smallest version difference to notify about: patch, minor or major
+	MinSeverity string `yaml:"minSeverity" toml:"minSeverity"`
+
+	// Timezone of QuietHours and DailySummary, defaults to the local time zone
+	Timezone   string     `yaml:"timezone" toml:"timezone"`
+	QuietHours QuietHours `yaml:"quietHours" toml:"quietHours"`
+
+	// DailySummary is a time like 09:00 to send a summary of every outdated image at, instead of changes
+	DailySummary string `yaml:"dailySummary" toml:"dailySummary"`
+
+	// Retries of a failed delivery with exponential backoff, defaults to 3
+	Retries *int `yaml:"retries" toml:"retries"`
+}
+
+// QuietHours hold back notifications between Start and End, e.g. 22:00 to 07:00.
+type QuietHours struct {
+	Start string `yaml:"start" toml:"start"`
+	End   string `yaml:"end" toml:"end"`
+}
+
+// State persists results and tag listings, so metrics are available right after a restart.
+type State struct {
+	// Store is where the state is kept: file, configmap or empty to disable persistence
+	Store string `yaml:"store" toml:"store"`
+
+	// Path of the BoltDB file of the file store
+	Path string `yaml:"path" toml:"path"`
+
+	// ConfigMap of the configmap store, the namespace defaults to the one of the exporter's pod
+	ConfigMap ConfigMapRef `yaml:"configMap" toml:"configMap"`
+
+	// Interval in which the state is saved, it is also saved on shutdown
+	Interval Duration `yaml:"interval" toml:"interval"`
+
+	// ExpireAfter drops restored results that weren't refreshed in time, e.g. because their container is gone
+	ExpireAfter Duration `yaml:"expireAfter" toml:"expireAfter"`
+
+	// LastTagsFallback evaluates images against the last known tags of their repository while the registry fails
+	LastTagsFallback bool `yaml:"lastTagsFallback" toml:"lastTagsFallback"`
+}
+
+// Telemetry exports the container metrics and traces of evaluations to an OpenTelemetry collector via OTLP/HTTP.
+type Telemetry struct {
+	// Endpoint of the collector, e.g. http://otel-collector:4318. Empty disables the export.
+	Endpoint string            `yaml:"endpoint" toml:"endpoint"`
+	Headers  map[string]string `yaml:"headers" toml:"headers"`
+
+	Metrics bool `yaml:"metrics" toml:"metrics"`
+	Traces  bool `yaml:"traces" toml:"traces"`
+
+	// Interval in which metrics are exported
+	Interval Duration `yaml:"interval" toml:"interval"`
+}
+
+type ConfigMapRef struct {
+	Namespace string `yaml:"namespace" toml:"namespace"`
+	Name      string `yaml:"name" toml:"name"`
+}
+
+// Duration is a time.Duration written like "5m" in the configuration file.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	d.Duration = duration
+
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
+
+func Default() Config {
+	return Config{
+		Sources: Sources{
+			Providers:          []string{"kubernetes"},
+			ImageCheckInterval: Duration{time.Hour},
+			Kubernetes: Kubernetes{
+				InCluster:              true,
+				InformerResyncInterval: Duration{5 * time.Minute},
+				Policies:               true,
+			},
+		},
+		Auth: Auth{
+			CredentialsFile: filepath.Join(homedir.HomeDir(), ".docker", "config.json"),
+		},
+		Evaluator: Evaluator{
+			Workers: 4,
+			Timeout: Duration{5 * time.Second},
+		},
+		Output: Output{
+			ListenAddr: ":8080",
+			LogLevel:   "info",
+			LogFormat:  "json",
+			Access: Access{
+				Metrics:   "none",
+				API:       "none",
+				Dashboard: "none",
+			},
+		},
+		Notifications: Notifications{
+			Interval: Duration{time.Minute},
+		},
+		State: State{
+			ConfigMap: ConfigMapRef{
+				Name: "outdated-image-exporter-state",
+			},
+			Interval:    Duration{time.Minute},
+			ExpireAfter: Duration{15 * time.Minute},
+		},
+		Telemetry: Telemetry{
+			Metrics:  true,
+			Traces:   true,
+			Interval: Duration{time.Minute},
+		},
+	}
+}
+
+// Load reads the configuration file on top of the defaults and applies environment overrides. The format is
+// chosen by the file extension, .toml for TOML and YAML otherwise. An empty path only applies the environment.
+func Load(path string) (Config, error) {
+	config := Default()
+
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+
+		if filepath.Ext(path) == ".toml" {
+			var metadata toml.MetaData
+
+			metadata, err = toml.NewDecoder(bytes.NewReader(content)).Decode(&config)
+			if undecoded := metadata.Undecoded(); err == nil && len(undecoded) > 0 {
+				keys := make([]string, 0, len(undecoded))
+				for _, key := range undecoded {
+					keys = append(keys, key.String())
+				}
+
+				err = fmt.Errorf("unknown keys %s", strings.Join(keys, ", "))
+			}
+		} else {
+			decoder := yaml.NewDecoder(bytes.NewReader(content))
+			decoder.KnownFields(true)
+
+			err = decoder.Decode(&config)
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+		}
+
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	err := applyEnvironment(&config, os.LookupEnv)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
@@ -0,0 +1,173 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/config"
+)
+
+func TestLoad(t *testing.T) {
+	for _, path := range []string{"testdata/config.yaml", "testdata/config.toml"} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			cfg, err := config.Load(path)
+			require.NoError(t, err)
+			require.NoError(t, cfg.Validate())
+
+			require.Equal(t, []string{"kubernetes", "docker"}, cfg.Sources.Providers)
+			require.Equal(t, 30*time.Minute, cfg.Sources.ImageCheckInterval.Duration)
+			require.False(t, cfg.Sources.Kubernetes.InCluster)
+			require.Equal(t, 10*time.Minute, cfg.Sources.Kubernetes.InformerResyncInterval.Duration)
+			require.True(t, cfg.Sources.Docker.SwarmServices)
+			require.Equal(t, []config.Registry{{Host: "registry.internal:5000", Insecure: true}}, cfg.Registries)
+			require.Equal(t, "/etc/exporter/config.json", cfg.Auth.CredentialsFile)
+			require.Equal(t, config.Credentials{Username: "bot", Password: "secret"}, cfg.Auth.Registries["ghcr.io"])
+			require.Equal(t, []config.Rewrite{{From: "docker.io", To: "mirror.internal/dockerhub"}}, cfg.Rewrites)
+			require.Equal(t, config.Policy{PinMode: "major", Constraint: "< 3.0"}, cfg.Policy)
+			require.Equal(t, 8, cfg.Evaluator.Workers)
+			require.Equal(t, 10*time.Second, cfg.Evaluator.Timeout.Duration)
+			require.Equal(t, config.Output{
+				ListenAddr:   ":9090",
+				LogLevel:     "debug",
+				LogFormat:    "text",
+				MetricLabels: []string{"app.kubernetes.io/name", "team"},
+				TLS:          config.TLS{CertFile: "/tls/tls.crt", KeyFile: "/tls/tls.key", ClientCAFile: "/tls/ca.crt"},
+				Access:       config.Access{Metrics: "rbac", API: "authenticated", Dashboard: "none"},
+			}, cfg.Output)
+			require.Equal(t, 5*time.Minute, cfg.Notifications.Interval.Duration)
+
+			require.Equal(t, config.State{
+				Store:       "configmap",
+				ConfigMap:   config.ConfigMapRef{Namespace: "monitoring", Name: "outdated-image-exporter-state"},
+				Interval:    config.Duration{Duration: 30 * time.Second},
+				ExpireAfter: config.Duration{Duration: 15 * time.Minute},
+			}, cfg.State)
+
+			require.Equal(t, config.Telemetry{
+				Endpoint: "http://otel-collector:4318",
+				Headers:  map[string]string{"Authorization": "Bearer secret"},
+				Metrics:  true,
+				Interval: config.Duration{Duration: time.Minute},
+			}, cfg.Telemetry)
+
+			retries := 5
+			require.Equal(t, []config.Receiver{{
+				Name:        "platform",
+				URL:         "https://hooks.slack.com/services/T000/B000/XXXX",
+				Format:      "slack",
+				Namespaces:  []string{"prod"},
+				MinSeverity: "minor",
+				Timezone:    "Europe/Berlin",
+				QuietHours:  config.QuietHours{Start: "22:00", End: "07:00"},
+				Retries:     &retries,
+			}}, cfg.Notifications.Receivers)
+		})
+	}
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.yaml")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	defaults := config.Default()
+	require.Equal(t, defaults, cfg)
+	require.Equal(t, 4, defaults.Evaluator.Workers)
+	require.Equal(t, time.Hour, defaults.Sources.ImageCheckInterval.Duration)
+}
+
+func TestLoad_UnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("evaluator:\n  worker: 2\n"), 0o600))
+
+	_, err := config.Load(path)
+	require.ErrorContains(t, err, "field worker not found")
+
+	path = filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte("[evaluator]\nworker = 2\n\n[output]\nlisten = \":9090\"\n"), 0o600))
+
+	_, err = config.Load(path)
+	require.ErrorContains(t, err, "unknown keys evaluator.worker, output.listen")
+}
+
+func TestLoad_Environment(t *testing.T) {
+	t.Setenv("OUTDATED_IMAGE_EXPORTER_EVALUATOR_TIMEOUT", "1m")
+	t.Setenv("OUTDATED_IMAGE_EXPORTER_SOURCES_PROVIDERS", "manifest, compose")
+	t.Setenv("OUTDATED_IMAGE_EXPORTER_SOURCES_KUBERNETES_IN_CLUSTER", "true")
+	t.Setenv("OUTDATED_IMAGE_EXPORTER_POLICY_PIN_MODE", "minor")
+
+	cfg, err := config.Load("testdata/config.yaml")
+	require.NoError(t, err)
+
+	require.Equal(t, time.Minute, cfg.Evaluator.Timeout.Duration)
+	require.Equal(t, []string{"manifest", "compose"}, cfg.Sources.Providers)
+	require.True(t, cfg.Sources.Kubernetes.InCluster)
+	require.Equal(t, "minor", cfg.Policy.PinMode)
+	require.Equal(t, 8, cfg.Evaluator.Workers)
+}
+
+func TestLoad_EnvironmentInvalid(t *testing.T) {
+	t.Setenv("OUTDATED_IMAGE_EXPORTER_EVALUATOR_WORKERS", "many")
+
+	_, err := config.Load("")
+	require.ErrorContains(t, err, "OUTDATED_IMAGE_EXPORTER_EVALUATOR_WORKERS (evaluator.workers)")
+}
+
+func TestValidate(t *testing.T) {
+	cfg := config.Default()
+	cfg.Sources.Providers = []string{"kubernetes", "podman"}
+	cfg.Sources.ImageCheckInterval.Duration = 0
+	cfg.Registries = []config.Registry{{Host: "registry.internal"}, {Host: "registry.internal"}}
+	cfg.Auth.Registries = map[string]config.Credentials{"ghcr.io": {Username: "bot"}}
+	cfg.Rewrites = []config.Rewrite{{From: "docker.io", To: "Mirror.internal/UPPER"}}
+	cfg.Policy.PinMode = "patch"
+	cfg.Policy.Constraint = "not a constraint"
+	cfg.Evaluator.Workers = 0
+	cfg.Output.LogFormat = "xml"
+	cfg.Output.MetricLabels = []string{"app.kubernetes.io/name", "app_kubernetes_io/name"}
+	cfg.Output.TLS.ClientCAFile = "/tls/ca.crt"
+	cfg.Output.Access.API = "token"
+	cfg.Notifications.Receivers = []config.Receiver{
+		{Name: "chat", URL: "https://chat.example.com/hook", Format: "slack", QuietHours: config.QuietHours{Start: "22:00"}},
+		{Name: "chat", URL: "ftp://example.com", Format: "email", MinSeverity: "none", DailySummary: "9am"},
+	}
+	cfg.State.Store = "file"
+	cfg.State.ExpireAfter.Duration = 0
+	cfg.Telemetry.Endpoint = "otel-collector:4318"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`sources.providers[1]: unsupported provider "podman"`,
+		"sources.imageCheckInterval: must be positive",
+		"registries[1].host: duplicate of registries[0]",
+		`auth.registries["ghcr.io"].password: is required together with username`,
+		"rewrites[0].to:",
+		"policy.pinMode:",
+		"policy.constraint:",
+		"evaluator.workers: must be at least 1",
+		`output.logFormat: unsupported format "xml"`,
+		`output.metricLabels: label name collision: source labels "app.kubernetes.io/name" and "app_kubernetes_io/name" are both exported as app_kubernetes_io_name`,
+		"output.tls.clientCAFile: requires certFile and keyFile",
+		`output.access.api: unsupported access "token", expected one of [none, authenticated, rbac]`,
+		"notifications.receivers[0].quietHours: start and end are required together",
+		"notifications.receivers[1].name: duplicate of notifications.receivers[0]",
+		`notifications.receivers[1].url: must be an http or https URL, got "ftp://example.com"`,
+		`notifications.receivers[1].template: unknown format "email"`,
+		`notifications.receivers[1].minSeverity: unsupported severity "none"`,
+		`notifications.receivers[1].dailySummary: invalid time of day "9am"`,
+		"state.path: is required for the file store",
+		"state.expireAfter: must be positive",
+		`telemetry.endpoint: must be an http or https URL, got "otel-collector:4318"`,
+	} {
+		require.ErrorContains(t, err, expected)
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix is prepended to the environment variables overriding configuration values. The rest of the name is the
+// path of the setting in upper snake case, e.g. OUTDATED_IMAGE_EXPORTER_EVALUATOR_TIMEOUT for evaluator.timeout.
+const EnvPrefix = "OUTDATED_IMAGE_EXPORTER_"
+
+// applyEnvironment overrides scalar settings and string lists from the environment. Lists are comma separated.
+// Lists of structs and maps, like rewrites and per-registry credentials, can only be set in the file.
+func applyEnvironment(config *Config, lookup func(string) (string, bool)) error {
+	return applyEnvironmentValue(reflect.ValueOf(config).Elem(), strings.TrimSuffix(EnvPrefix, "_"), "", lookup)
+}
+
+func applyEnvironmentValue(value reflect.Value, envName, path string, lookup func(string) (string, bool)) error {
+	if value.Kind() == reflect.Struct && !value.Addr().Type().Implements(textUnmarshalerType) {
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+
+			err := applyEnvironmentValue(value.Field(i), envName+"_"+snakeCase(name), fieldPath, lookup)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	raw, ok := lookup(envName)
+	if !ok {
+		return nil
+	}
+
+	err := setValue(value, raw)
+	if err != nil {
+		return fmt.Errorf("%s (%s): %w", envName, path, err)
+	}
+
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func setValue(value reflect.Value, raw string) error {
+	if value.CanAddr() && value.Addr().Type().Implements(textUnmarshalerType) {
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		value.SetBool(parsed)
+	case reflect.Int:
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(int64(parsed))
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("can only be set in the config file")
+		}
+
+		var items []string
+
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("can only be set in the config file")
+	}
+
+	return nil
+}
+
+// snakeCase converts a camelCase setting name to upper snake case, e.g. imageCheckInterval to IMAGE_CHECK_INTERVAL.
+func snakeCase(name string) string {
+	var builder strings.Builder
+
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 {
+			builder.WriteByte('_')
+		}
+
+		builder.WriteRune(unicode.ToUpper(r))
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/exporter"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/notify"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+var providers = []string{"kubernetes", "docker", "manifest", "dockerfile", "compose"}
+
+// Validate checks the whole configuration and returns every problem at once, each prefixed with the path of the
+// setting.
+func (c Config) Validate() error {
+	var errs []error
+
+	fail := func(path, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	if len(c.Sources.Providers) == 0 {
+		fail("sources.providers", "at least one provider is required")
+	}
+
+	for i, provider := range c.Sources.Providers {
+		if !slices.Contains(providers, provider) {
+			fail(fmt.Sprintf("sources.providers[%d]", i), "unsupported provider %q, expected one of %v", provider, providers)
+		}
+	}
+
+	if c.Sources.ImageCheckInterval.Duration <= 0 {
+		fail("sources.imageCheckInterval", "must be positive, got %s", c.Sources.ImageCheckInterval)
+	}
+
+	if c.Sources.Kubernetes.InformerResyncInterval.Duration < 0 {
+		fail("sources.kubernetes.informerResyncInterval", "must not be negative, got %s", c.Sources.Kubernetes.InformerResyncInterval)
+	}
+
+	hosts := map[string]int{}
+
+	for i, registry := range c.Registries {
+		path := fmt.Sprintf("registries[%d].host", i)
+
+		if registry.Host == "" {
+			fail(path, "is required")
+
+			continue
+		}
+
+		if _, err := name.NewRegistry(registry.Host); err != nil {
+			fail(path, "%v", err)
+		}
+
+		if previous, ok := hosts[registry.Host]; ok {
+			fail(path, "duplicate of registries[%d]", previous)
+		}
+
+		hosts[registry.Host] = i
+	}
+
+	for host, credentials := range c.Auth.Registries {
+		path := fmt.Sprintf("auth.registries[%q]", host)
+
+		if credentials.Username == "" && credentials.IdentityToken == "" {
+			fail(path, "username or identityToken is required")
+		}
+
+		if credentials.Username != "" && credentials.Password == "" {
+			fail(path+".password", "is required together with username")
+		}
+	}
+
+	for i, rewrite := range c.Rewrites {
+		if _, err := tags.NormalizeRepository(rewrite.From); err != nil {
+			fail(fmt.Sprintf("rewrites[%d].from", i), "%v", err)
+		}
+
+		if _, err := tags.NormalizeRepository(rewrite.To); err != nil {
+			fail(fmt.Sprintf("rewrites[%d].to", i), "%v", err)
+		}
+	}
+
+	if _, err := version.ParsePinMode(c.Policy.PinMode); err != nil {
+		fail("policy.pinMode", "%v", err)
+	}
+
+	if _, err := version.ParseConstraint(c.Policy.Constraint); err != nil {
+		fail("policy.constraint", "%v", err)
+	}
+
+	if c.Evaluator.Workers < 1 {
+		fail("evaluator.workers", "must be at least 1, got %d", c.Evaluator.Workers)
+	}
+
+	if c.Evaluator.Timeout.Duration <= 0 {
+		fail("evaluator.timeout", "must be positive, got %s", c.Evaluator.Timeout)
+	}
+
+	if c.Output.ListenAddr == "" {
+		fail("output.listenAddr", "is required")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Output.LogLevel)); err != nil {
+		fail("output.logLevel", "%v", err)
+	}
+
+	if c.Output.LogFormat != "json" && c.Output.LogFormat != "text" {
+		fail("output.logFormat", "unsupported format %q, expected json or text", c.Output.LogFormat)
+	}
+
+	if err := evaluation.ValidateLabels(c.Output.MetricLabels); err != nil {
+		fail("output.metricLabels", "%v", err)
+	}
+
+	if (c.Output.TLS.CertFile == "") != (c.Output.TLS.KeyFile == "") {
+		fail("output.tls", "certFile and keyFile are required together")
+	}
+
+	if c.Output.TLS.ClientCAFile != "" && c.Output.TLS.CertFile == "" {
+		fail("output.tls.clientCAFile", "requires certFile and keyFile")
+	}
+
+	for _, endpoint := range []struct{ field, value string }{
+		{"metrics", c.Output.Access.Metrics},
+		{"api", c.Output.Access.API},
+		{"dashboard", c.Output.Access.Dashboard},
+	} {
+		if _, err := exporter.ParseAccess(endpoint.value); err != nil {
+			fail("output.access."+endpoint.field, "%v", err)
+		}
+	}
+
+	if c.Notifications.Interval.Duration <= 0 {
+		fail("notifications.interval", "must be positive, got %s", c.Notifications.Interval)
+	}
+
+	names := map[string]int{}
+
+	for i, receiver := range c.Notifications.Receivers {
+		path := fmt.Sprintf("notifications.receivers[%d]", i)
+
+		if receiver.Name == "" {
+			fail(path+".name", "is required")
+		} else if previous, ok := names[receiver.Name]; ok {
+			fail(path+".name", "duplicate of notifications.receivers[%d]", previous)
+		} else {
+			names[receiver.Name] = i
+		}
+
+		if receiverURL, err := url.Parse(receiver.URL); err != nil || (receiverURL.Scheme != "http" && receiverURL.Scheme != "https") {
+			fail(path+".url", "must be an http or https URL, got %q", receiver.URL)
+		}
+
+		if _, err := notify.ParseTemplate(receiver.Format, receiver.Template); err != nil {
+			fail(path+".template", "%v", err)
+		}
+
+		if receiver.MinSeverity != "" {
+			if severity, err := evaluation.ParseSeverity(receiver.MinSeverity); err != nil || severity == evaluation.SeverityNone {
+				fail(path+".minSeverity", "unsupported severity %q, expected one of [patch, minor, major]", receiver.MinSeverity)
+			}
+		}
+
+		if _, err := time.LoadLocation(receiver.Timezone); err != nil {
+			fail(path+".timezone", "%v", err)
+		}
+
+		if (receiver.QuietHours.Start == "") != (receiver.QuietHours.End == "") {
+			fail(path+".quietHours", "start and end are required together")
+		}
+
+		for _, timeOfDay := range []struct{ field, value string }{
+			{"quietHours.start", receiver.QuietHours.Start},
+			{"quietHours.end", receiver.QuietHours.End},
+			{"dailySummary", receiver.DailySummary},
+		} {
+			if _, err := notify.ParseTimeOfDay(timeOfDay.value); timeOfDay.value != "" && err != nil {
+				fail(path+"."+timeOfDay.field, "%v", err)
+			}
+		}
+
+		if receiver.Retries != nil && *receiver.Retries < 0 {
+			fail(path+".retries", "must not be negative, got %d", *receiver.Retries)
+		}
+	}
+
+	switch c.State.Store {
+	case "":
+	case "file":
+		if c.State.Path == "" {
+			fail("state.path", "is required for the file store")
+		}
+	case "configmap":
+		if c.State.ConfigMap.Name == "" {
+			fail("state.configMap.name", "is required for the configmap store")
+		}
+	default:
+		fail("state.store", "unsupported store %q, expected file or configmap", c.State.Store)
+	}
+
+	if c.State.Interval.Duration <= 0 {
+		fail("state.interval", "must be positive, got %s", c.State.Interval)
+	}
+
+	if c.State.ExpireAfter.Duration <= 0 {
+		fail("state.expireAfter", "must be positive, got %s", c.State.ExpireAfter)
+	}
+
+	if c.Telemetry.Endpoint != "" {
+		if endpoint, err := url.Parse(c.Telemetry.Endpoint); err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+			fail("telemetry.endpoint", "must be an http or https URL, got %q", c.Telemetry.Endpoint)
+		}
+	}
+
+	if c.Telemetry.Interval.Duration <= 0 {
+		fail("telemetry.interval", "must be positive, got %s", c.Telemetry.Interval)
+	}
+
+	return errors.Join(errs...)
+}
@@ -2,83 +2,296 @@ package evaluation
 
 import (
 	"context"
+	"errors"
 	"log/slog"
-	"regexp"
+	"maps"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/metrics"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/policy"
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
 	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
 )
 
+var tracer = otel.Tracer("github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation")
+
 type ContainerClient interface {
 	Listener(ctx context.Context) (<-chan clients.ContainerImage, error)
 }
 
+type Config struct {
+	// DefaultPolicy applies to every image, annotations override it
+	DefaultPolicy version.Policy
+
+	// Workers is the number of images evaluated in parallel, defaults to 4
+	Workers int
+
+	// Timeout for evaluating a single image, defaults to 5s
+	Timeout time.Duration
+
+	// Policies are matched against every image, the most specific one applies between the default policy and the
+	// annotations. Nil disables policies.
+	Policies *policy.Store
+
+	// CacheTTL is how long the evaluation of an image reference is shared by every container using it with the same
+	// policy. Zero evaluates every container on its own.
+	CacheTTL time.Duration
+
+	// Labels of the container sources copied to the metrics, e.g. pod labels. They are read once by NewEvaluator.
+	Labels []string
+
+	// LastTagsFallback evaluates images against the last known tags of their repository if listing the tags fails.
+	// The result is marked stale. Disabled, a failed listing fails the check.
+	LastTagsFallback bool
+
+	// Watch means the sources keep watching for changes, so a closed source channel is a failure and Run returns
+	// ErrSourcesStopped. Disabled, the sources report every container image once. It is read once by NewEvaluator.
+	Watch bool
+}
+
+// ErrSourcesStopped is returned by Run if watching sources closed their channel, e.g. after a failed event stream
+var ErrSourcesStopped = errors.New("container sources stopped watching")
+
+// Observer is notified about every stored or removed result. Calls happen on the evaluator's workers, so
+// observers should hand off slow work.
+type Observer interface {
+	ResultUpdated(result Result)
+	ResultRemoved(result Result)
+}
+
 type Evaluator struct {
+	Config      Config
+	configMutex sync.RWMutex
+
+	observers []Observer
+
 	containerClient ContainerClient
+	watch           bool
 	tagLister       *tags.TagLister
 	versionChecker  *version.Checker
 	logger          *slog.Logger
 
 	podContainers map[string][]string
-	labelCache    map[string]prometheus.Labels
+	schema        *labelSchema
 	metrics       map[string][]Metric
+	results       map[string]Result
 	metricsMutex  sync.RWMutex
+
+	// restored holds the names of restored results whose metrics were not refreshed yet
+	restored map[string]struct{}
+
+	// images caches evaluations per image reference and policy, imageUsers tracks the containers using them
+	images      map[string]imageEvaluation
+	imageUsers  map[string]imageUser
+	imagesMutex sync.Mutex
+	imageGroup  singleflight.Group
+
+	healthState healthState
+}
+
+type imageEvaluation struct {
+	evaluation version.Evaluation
+	stale      bool
+	checkedAt  time.Time
+
+	// listErr is why a stale evaluation fell back to the last known tags
+	listErr error
+}
+
+type imageUser struct {
+	key    string
+	policy *policy.Policy
 }
 
 type Metric struct {
+	Name   string
 	Labels prometheus.Labels
 	Value  float64
 }
 
+const (
+	metricOutdated        = "container_image_outdated"
+	metricPolicyViolation = "container_image_policy_violation"
+	metricCheckStatus     = "container_image_check_status"
+	metricLastSuccess     = "container_image_last_success_timestamp_seconds"
+	metricInfo            = "container_image_info"
+)
+
+var metricHelp = map[string]string{
+	metricOutdated:        "Exports how many major, minor or patch versions a image in a podspec is outdated",
+	metricPolicyViolation: "Is 1 if an image is further behind than the alert thresholds of its ImageUpdatePolicy allow",
+	metricCheckStatus:     "Is 1 for the reason the last check of an image failed, or ok if it succeeded",
+	metricLastSuccess:     "Unix time of the last successful check of an image",
+	metricInfo:            "Is 1 for every evaluated image, labelled with its current tag and digest and the newest tags to update to",
+}
+
+// MetricHelp returns the help text of every container metric by name.
+func MetricHelp() map[string]string {
+	return maps.Clone(metricHelp)
+}
+
 func NewEvaluator(
+	config Config,
 	tagLister *tags.TagLister,
 	versionChecker *version.Checker,
 	containerClient ContainerClient,
 	logger *slog.Logger,
 ) (*Evaluator, error) {
+	schema, err := newLabelSchema(config.Labels)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Evaluator{
+		Config:          config,
 		containerClient: containerClient,
+		watch:           config.Watch,
 		tagLister:       tagLister,
 		versionChecker:  versionChecker,
 		logger:          logger,
 
-		labelCache: map[string]prometheus.Labels{},
+		schema:     schema,
 		metrics:    map[string][]Metric{},
+		results:    map[string]Result{},
+		restored:   map[string]struct{}{},
+		images:     map[string]imageEvaluation{},
+		imageUsers: map[string]imageUser{},
+
+		healthState: healthState{inFlight: map[int]time.Time{}},
 	}, nil
 }
 
+// SetConfig replaces the configuration while the evaluator is running. The number of workers only takes effect on
+// the next call to Run.
+func (e *Evaluator) SetConfig(config Config) {
+	e.configMutex.Lock()
+	defer e.configMutex.Unlock()
+
+	e.Config = config
+}
+
+// matchPolicy returns the most specific policy for the image, nil if there is none.
+func (c Config) matchPolicy(containerImage clients.ContainerImage) *policy.Policy {
+	if c.Policies == nil {
+		return nil
+	}
+
+	matchedPolicy, _ := c.Policies.Match(containerImage)
+
+	return matchedPolicy
+}
+
+func (e *Evaluator) config() Config {
+	e.configMutex.RLock()
+	defer e.configMutex.RUnlock()
+
+	config := e.Config
+
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	return config
+}
+
+// AddObserver registers an observer. It must be called before Run.
+func (e *Evaluator) AddObserver(observer Observer) {
+	e.observers = append(e.observers, observer)
+}
+
 func (e *Evaluator) Run(ctx context.Context) error {
+	e.healthState.update(func(health *Health) {
+		health.Running = true
+		health.Synced = false
+		health.Initialized = false
+		health.Finished = false
+		health.Stopped = false
+	})
+
+	defer e.healthState.update(func(health *Health) {
+		health.Running = false
+	})
+
 	containerImages, err := e.containerClient.Listener(ctx)
 	if err != nil {
 		return err
 	}
 
+	e.healthState.update(func(health *Health) {
+		health.Synced = true
+	})
+
+	queue := make(chan clients.ContainerImage)
+
+	var pending sync.WaitGroup
+
+	// The dispatcher hands container images to the workers. Once the sources marked their listing as complete, it
+	// waits for the images handed out so far before setting Initialized.
+	go func() {
+		defer close(queue)
+
+		for containerImage := range containerImages {
+			if containerImage.Action == clients.ContainerImagesListed {
+				pending.Wait()
+
+				e.healthState.update(func(health *Health) {
+					health.Initialized = true
+				})
+
+				continue
+			}
+
+			pending.Add(1)
+			queue <- containerImage
+		}
+	}()
+
 	wg := sync.WaitGroup{}
 
-	for range 4 {
+	for worker := range e.config().Workers {
 		wg.Add(1)
 
 		go func() {
-			for containerImage := range containerImages {
+			for containerImage := range queue {
 				e.logger.Info("next container to check", "name", containerImage.Name, "image", containerImage.Image, "action", containerImage.Action.String())
 
+				metrics.SourceEvents.WithLabelValues(containerImage.Action.String()).Inc()
+
+				e.healthState.started(worker)
+
+				evaluated := false
+
 				switch containerImage.Action {
 				case clients.ContainerImageRemoved:
-					e.metricsMutex.Lock()
-					delete(e.metrics, containerImage.Name)
-					e.metricsMutex.Unlock()
+					e.remove(containerImage.Name)
 
 				case clients.ContainerImageAdded:
-					err = e.handleContainerImageAdded(ctx, containerImage)
+					err := e.handleContainerImageAdded(ctx, containerImage, false)
 					if err != nil {
 						e.logger.Error("error handling container image added", "name", containerImage.Name, "image", containerImage.Image, "error", err)
 					}
+
+					evaluated = err == nil
 				}
+
+				e.healthState.done(worker, evaluated)
+				pending.Done()
 			}
 
 			wg.Done()
@@ -87,113 +300,520 @@ func (e *Evaluator) Run(ctx context.Context) error {
 
 	wg.Wait()
 
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	e.healthState.update(func(health *Health) {
+		health.Finished = !e.watch
+		health.Stopped = e.watch
+	})
+
+	if e.watch {
+		return ErrSourcesStopped
+	}
+
+	return nil
+}
+
+// handleContainerImageAdded evaluates a container image and stores the result. With onlyKnown the result is dropped
+// if the container was removed in the meantime, so a recheck doesn't bring it back.
+func (e *Evaluator) handleContainerImageAdded(ctx context.Context, containerImage clients.ContainerImage, onlyKnown bool) error {
+	config := e.config()
+
+	matchedPolicy := config.matchPolicy(containerImage)
+
+	if matchedPolicy != nil && matchedPolicy.Ignore {
+		e.logger.Debug("image ignored by policy", "name", containerImage.Name, "image", containerImage.Image, "policy", matchedPolicy.Name)
+
+		e.remove(containerImage.Name)
+
+		return nil
+	}
+
+	start := time.Now()
+
+	result, key, fresh, err := e.evaluate(ctx, containerImage, config, matchedPolicy)
+	if fresh || err != nil {
+		metrics.EvaluationDuration.WithLabelValues(CheckReason(err)).Observe(time.Since(start).Seconds())
+	}
+
+	if err != nil {
+		e.store(Result{
+			ContainerImage: containerImage,
+			Err:            err,
+			Reason:         CheckReason(err),
+			CheckedAt:      time.Now(),
+		}, nil, onlyKnown)
+
+		return err
+	}
+
+	if !e.store(result, e.containerMetrics(result, matchedPolicy), onlyKnown) {
+		return nil
+	}
+
+	if config.CacheTTL > 0 {
+		e.addImageUser(containerImage.Name, key, matchedPolicy)
+	}
+
+	if fresh {
+		e.fanOut(containerImage.Name, key, result)
+	}
+
 	return nil
 }
 
-func (e *Evaluator) handleContainerImageAdded(ctx context.Context, containerImage clients.ContainerImage) error {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+func (e *Evaluator) containerMetrics(result Result, matchedPolicy *policy.Policy) []Metric {
+	labels := e.schema.labels(result.ContainerImage)
+	labels[labelStale] = strconv.FormatBool(result.Stale)
+
+	metrics := []Metric{{
+		Name:   metricOutdated,
+		Labels: withLabelValues(labels, labelType, "major"),
+		Value:  float64(result.Major),
+	}, {
+		Name:   metricOutdated,
+		Labels: withLabelValues(labels, labelType, "minor"),
+		Value:  float64(result.Minor),
+	}, {
+		Name:   metricOutdated,
+		Labels: withLabelValues(labels, labelType, "patch"),
+		Value:  float64(result.Patch),
+	}}
+
+	metrics = append(metrics, infoMetric(result, labels))
+
+	if matchedPolicy != nil && matchedPolicy.Alert != nil {
+		violation := 0.0
+		if matchedPolicy.Violated(result.Major, result.Minor, result.Patch) {
+			violation = 1
+		}
+
+		metrics = append(metrics, Metric{
+			Name:   metricPolicyViolation,
+			Labels: withLabelValues(labels, labelPolicy, matchedPolicy.Name),
+			Value:  violation,
+		})
+	}
+
+	return metrics
+}
+
+// infoMetric names the current tag and digest of a container image and the newest patch, minor and major tags.
+func infoMetric(result Result, labels prometheus.Labels) Metric {
+	info := copyLabels(labels)
+	delete(info, labelStale)
+
+	info[labelCurrentTag] = result.Current
+	info[labelLatestPatch] = result.LatestPatch
+	info[labelLatestMinor] = result.LatestMinor
+	info[labelLatestMajor] = result.LatestMajor
+
+	info[labelCurrentDigest], _ = result.ContainerImage.Metadata[clients.MetadataDigest].(string)
+	if info[labelCurrentDigest] == "" {
+		info[labelCurrentDigest] = clients.DigestOf(result.ContainerImage.Image)
+	}
+
+	if ref, err := name.ParseReference(result.ContainerImage.Image); err == nil {
+		info[labelRegistry] = ref.Context().RegistryStr()
+	}
+
+	return Metric{
+		Name:   metricInfo,
+		Labels: info,
+		Value:  1,
+	}
+}
+
+// store saves the result of a container image and notifies the observers. Nil metrics keep the previous ones, so
+// a failed check doesn't reset the exported values. With onlyKnown nothing is stored if the container was removed,
+// and store returns false.
+func (e *Evaluator) store(result Result, metrics []Metric, onlyKnown bool) bool {
+	e.metricsMutex.Lock()
+	if _, known := e.results[result.ContainerImage.Name]; onlyKnown && !known {
+		e.metricsMutex.Unlock()
+
+		return false
+	}
+
+	if result.Err == nil && !result.Stale {
+		result.LastSuccess = result.CheckedAt
+	} else if previous, ok := e.results[result.ContainerImage.Name]; ok {
+		result.LastSuccess = previous.LastSuccess
+	}
+	e.results[result.ContainerImage.Name] = result
+	if metrics != nil {
+		e.metrics[result.ContainerImage.Name] = metrics
+
+		// restored metrics that weren't replaced still expire
+		delete(e.restored, result.ContainerImage.Name)
+	}
+	e.metricsMutex.Unlock()
+
+	for _, observer := range e.observers {
+		observer.ResultUpdated(result)
+	}
+
+	return true
+}
+
+// remove forgets a container image and notifies the observers with its last result.
+func (e *Evaluator) remove(name string) {
+	e.metricsMutex.Lock()
+	result, ok := e.results[name]
+	delete(e.metrics, name)
+	delete(e.results, name)
+	delete(e.restored, name)
+	e.metricsMutex.Unlock()
+
+	e.imagesMutex.Lock()
+	delete(e.imageUsers, name)
+	e.imagesMutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	for _, observer := range e.observers {
+		observer.ResultRemoved(result)
+	}
+}
+
+// Evaluate checks a single container image with the current configuration and policies, without storing the
+// result. Images ignored by a policy return a result with the policy's name and no difference.
+func (e *Evaluator) Evaluate(ctx context.Context, containerImage clients.ContainerImage) (Result, error) {
+	config := e.config()
+
+	matchedPolicy := config.matchPolicy(containerImage)
+
+	if matchedPolicy != nil && matchedPolicy.Ignore {
+		return Result{
+			ContainerImage: containerImage,
+			Policy:         matchedPolicy.Name,
+			Reason:         ReasonOK,
+			CheckedAt:      time.Now(),
+		}, nil
+	}
+
+	result, _, _, err := e.evaluate(ctx, containerImage, config, matchedPolicy)
+
+	return result, err
+}
+
+// evaluate checks a container image, reusing the evaluation of other containers with the same image and policy.
+// It returns the cache key of the evaluation and whether it was fresh from the registry.
+func (e *Evaluator) evaluate(
+	ctx context.Context,
+	containerImage clients.ContainerImage,
+	config Config,
+	matchedPolicy *policy.Policy,
+) (Result, string, bool, error) {
+	ctx, span := tracer.Start(ctx, "evaluate", trace.WithAttributes(
+		attribute.String("container.name", containerImage.Name),
+		attribute.String("container.image.name", containerImage.Image),
+	))
+
+	result, key, fresh, err := e.evaluateContainer(ctx, containerImage, config, matchedPolicy)
+
+	span.SetAttributes(
+		attribute.String("policy", result.Policy),
+		attribute.Bool("cached", err == nil && !fresh),
+		attribute.String("severity", result.Severity().String()),
+	)
+	endSpan(span, err)
+
+	return result, key, fresh, err
+}
+
+func (e *Evaluator) evaluateContainer(
+	ctx context.Context,
+	containerImage clients.ContainerImage,
+	config Config,
+	matchedPolicy *policy.Policy,
+) (Result, string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
 	defer cancel()
 
 	logger := e.logger.With("name", containerImage.Name, "image", containerImage.Image)
 
-	var pinMode version.PinMode
+	imagePolicy := config.DefaultPolicy
+
+	var (
+		policyName string
+		err        error
+	)
 
-	switch containerImage.Annotations["outdated-images.patrick246.de/pin-mode"] {
-	case "major":
-		pinMode = version.PIN_MAJOR
-	case "minor":
-		pinMode = version.PIN_MINOR
-	default:
-		pinMode = version.PIN_NONE
+	if matchedPolicy != nil {
+		policyName = matchedPolicy.Name
+
+		imagePolicy = matchedPolicy.Apply(imagePolicy)
 	}
 
-	imageKeychain, ok := containerImage.Metadata["DockerKeychain"].(*tags.DockerConfigKeychain)
+	imagePolicy, err = version.PolicyFromAnnotations(containerImage.Annotations, imagePolicy)
+	if err != nil {
+		return Result{}, "", false, err
+	}
+
+	imageKeychain, ok := containerImage.Metadata[clients.MetadataDockerKeychain].(*tags.DockerConfigKeychain)
 	if !ok {
 		imageKeychain = &tags.DockerConfigKeychain{}
 	}
 
-	logger.InfoContext(ctx, "checking container")
+	// Containers only share evaluations if they use the same credentials, a container without access to a private
+	// image must not see its tags
+	key := containerImage.Image + " " + imagePolicy.Key() + " " + e.tagLister.Identity(containerImage.Image, imageKeychain)
 
-	currentVersion, err := e.tagLister.GetTagOfImage(containerImage.Image)
+	evaluated, fresh, err := e.evaluateImage(ctx, key, containerImage.Image, imageKeychain, imagePolicy, config.CacheTTL, logger)
 	if err != nil {
-		return err
+		return Result{}, "", false, err
 	}
 
-	logger.Debug("fetching image tags")
+	evaluation := evaluated.evaluation
 
-	imageTags, err := e.tagLister.ListTags(ctx, containerImage.Image, imageKeychain)
-	if err != nil {
-		return err
+	if evaluation.Major != 0 || evaluation.Minor != 0 || evaluation.Patch != 0 {
+		logger.InfoContext(ctx, "image outdated", "major", evaluation.Major, "minor", evaluation.Minor, "patch", evaluation.Patch, "cached", !fresh)
+	} else {
+		logger.InfoContext(ctx, "image up-to-date", "current", evaluation.Current, "cached", !fresh)
 	}
 
-	logger.Debug("got image tags", "count", len(imageTags))
+	return Result{
+		ContainerImage: containerImage,
+		Evaluation:     evaluation,
+		Policy:         policyName,
+		Stale:          evaluated.stale,
+		Reason:         CheckReason(evaluated.listErr),
+		CheckedAt:      evaluated.checkedAt,
+	}, key, fresh, nil
+}
+
+// evaluateImage lists the tags of an image and evaluates them, unless an evaluation with the same key is younger
+// than the TTL. Concurrent calls with the same key share one registry request, only the call doing the request
+// reports the evaluation as fresh. The key includes the credentials, failed and stale evaluations are not cached.
+func (e *Evaluator) evaluateImage(
+	ctx context.Context,
+	key, image string,
+	keychain *tags.DockerConfigKeychain,
+	imagePolicy version.Policy,
+	ttl time.Duration,
+	logger *slog.Logger,
+) (imageEvaluation, bool, error) {
+	if cached, ok := e.cachedImage(key, ttl); ok {
+		return cached, false, nil
+	}
+
+	fresh := false
+
+	evaluated, err, _ := e.imageGroup.Do(key, func() (any, error) {
+		// another call may have finished between the cache lookup and joining the group
+		if cached, ok := e.cachedImage(key, ttl); ok {
+			return cached, nil
+		}
+
+		fresh = true
+
+		logger.InfoContext(ctx, "checking container")
+
+		currentVersion, err := e.tagLister.GetTagOfImage(image)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Debug("fetching image tags")
+
+		var listErr error
+
+		imageTags, err := e.tagLister.ListTags(ctx, image, keychain)
+		e.healthState.listed(err)
 
-	major, minor, patch, err := e.versionChecker.GetDifference(currentVersion, imageTags, pinMode)
+		if err != nil {
+			tagList, ok := e.tagLister.LastTags(image)
+			if !ok || !e.config().LastTagsFallback {
+				return nil, err
+			}
+
+			logger.Warn("error listing tags, using the last known tags", "listedAt", tagList.ListedAt, "error", err)
+
+			imageTags = tagList.Tags
+			listErr = err
+		}
+
+		logger.Debug("got image tags", "count", len(imageTags))
+
+		_, span := tracer.Start(ctx, "compare versions", trace.WithAttributes(
+			attribute.String("version.current", currentVersion),
+			attribute.Int("image.tags", len(imageTags)),
+		))
+
+		evaluation, err := e.versionChecker.Evaluate(currentVersion, imageTags, imagePolicy)
+		if err == nil {
+			span.SetAttributes(attribute.String("version.latest", evaluation.Latest))
+		}
+
+		endSpan(span, err)
+
+		if err != nil {
+			return nil, err
+		}
+
+		evaluated := imageEvaluation{
+			evaluation: evaluation,
+			stale:      listErr != nil,
+			checkedAt:  time.Now(),
+			listErr:    listErr,
+		}
+
+		if ttl > 0 && listErr == nil {
+			e.cacheImage(key, evaluated, ttl)
+		}
+
+		return evaluated, nil
+	})
 	if err != nil {
-		return err
+		return imageEvaluation{}, false, err
 	}
 
-	if major != 0 || minor != 0 || patch != 0 {
-		logger.InfoContext(ctx, "image outdated", "major", major, "minor", minor, "patch", patch)
-	} else {
-		logger.InfoContext(ctx, "image up-to-date", "current", currentVersion)
+	return evaluated.(imageEvaluation), fresh, nil
+}
+
+func (e *Evaluator) cachedImage(key string, ttl time.Duration) (imageEvaluation, bool) {
+	if ttl <= 0 {
+		return imageEvaluation{}, false
 	}
 
-	labels := prometheus.Labels{
-		"container": containerImage.Name,
+	e.imagesMutex.Lock()
+	defer e.imagesMutex.Unlock()
+
+	cached, ok := e.images[key]
+
+	return cached, ok && time.Since(cached.checkedAt) < ttl
+}
+
+// cacheImage stores an evaluation and drops expired ones.
+func (e *Evaluator) cacheImage(key string, evaluated imageEvaluation, ttl time.Duration) {
+	e.imagesMutex.Lock()
+	defer e.imagesMutex.Unlock()
+
+	for cachedKey, cached := range e.images {
+		if time.Since(cached.checkedAt) >= ttl {
+			delete(e.images, cachedKey)
+		}
 	}
 
-	for labelKey, labelValue := range containerImage.Labels {
-		labels[labelKey] = labelValue
+	e.images[key] = evaluated
+}
+
+func (e *Evaluator) addImageUser(name, key string, matchedPolicy *policy.Policy) {
+	e.imagesMutex.Lock()
+	defer e.imagesMutex.Unlock()
+
+	e.imageUsers[name] = imageUser{key: key, policy: matchedPolicy}
+}
+
+// fanOut passes a fresh evaluation on to the other containers using the same image and policy, so they don't wait
+// for their own recheck.
+func (e *Evaluator) fanOut(name, key string, result Result) {
+	e.imagesMutex.Lock()
+
+	users := map[string]*policy.Policy{}
+
+	for user, usage := range e.imageUsers {
+		if user != name && usage.key == key {
+			users[user] = usage.policy
+		}
 	}
 
-	e.metricsMutex.Lock()
-	e.metrics[containerImage.Name] = []Metric{{
-		Labels: withLabelValues(labels, "type", "major"),
-		Value:  float64(major),
-	}, {
-		Labels: withLabelValues(labels, "type", "minor"),
-		Value:  float64(minor),
-	}, {
-		Labels: withLabelValues(labels, "type", "patch"),
-		Value:  float64(patch),
-	}}
-	e.metricsMutex.Unlock()
+	e.imagesMutex.Unlock()
 
-	return nil
+	for user, matchedPolicy := range users {
+		e.metricsMutex.RLock()
+		previous, ok := e.results[user]
+		e.metricsMutex.RUnlock()
+
+		if !ok {
+			continue
+		}
+
+		updated := previous
+		updated.Evaluation = result.Evaluation
+		updated.Stale = result.Stale
+		updated.Reason = result.Reason
+		updated.Err = nil
+		updated.CheckedAt = result.CheckedAt
+
+		e.store(updated, e.containerMetrics(updated, matchedPolicy), true)
+	}
+}
+
+// Results returns the last result of every container image, sorted by name.
+func (e *Evaluator) Results() []Result {
+	e.metricsMutex.RLock()
+	defer e.metricsMutex.RUnlock()
+
+	results := make([]Result, 0, len(e.results))
+	for _, result := range e.results {
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ContainerImage.Name < results[j].ContainerImage.Name
+	})
+
+	return results
+}
+
+// Describe sends the descriptors of every container metric.
+func (e *Evaluator) Describe(descs chan<- *prometheus.Desc) {
+	e.schema.describe(descs)
 }
 
 func (e *Evaluator) Metrics() []prometheus.Metric {
+	return e.schema.constMetrics(e.Series())
+}
+
+// Series returns the container metrics with their complete labels, one per series like they are exported to
+// Prometheus.
+func (e *Evaluator) Series() []Metric {
 	e.metricsMutex.RLock()
 	defer e.metricsMutex.RUnlock()
 
-	result := make([]prometheus.Metric, 0, len(e.metrics))
+	metrics := make([]Metric, 0, 4*len(e.metrics)+2*len(e.results))
 
 	for _, containerMetrics := range e.metrics {
-		for _, metric := range containerMetrics {
-			labelKeys := make([]string, 0, len(metric.Labels))
-			labelValues := make([]string, 0, len(metric.Labels))
+		metrics = append(metrics, containerMetrics...)
+	}
 
-			for key, value := range metric.Labels {
-				labelKeys = append(labelKeys, sanitizeLabelKey(key))
-				labelValues = append(labelValues, value)
-			}
+	for _, containerResult := range e.results {
+		metrics = append(metrics, e.statusMetrics(containerResult)...)
+	}
 
-			result = append(result, prometheus.MustNewConstMetric(
-				prometheus.NewDesc(
-					"container_image_outdated",
-					"Exports how many major, minor or patch versions a image in a podspec is outdated",
-					labelKeys,
-					nil,
-				),
-				prometheus.GaugeValue,
-				metric.Value,
-				labelValues...,
-			))
-		}
+	return e.schema.deduplicate(metrics)
+}
+
+// statusMetrics report whether the last check of a container succeeded and when it last did.
+func (e *Evaluator) statusMetrics(result Result) []Metric {
+	labels := e.schema.labels(result.ContainerImage)
+
+	reason := result.Reason
+	if reason == "" {
+		reason = CheckReason(result.Err)
 	}
 
-	return result
+	metrics := []Metric{{
+		Name:   metricCheckStatus,
+		Labels: withLabelValues(labels, labelReason, reason),
+		Value:  1,
+	}}
+
+	if !result.LastSuccess.IsZero() {
+		metrics = append(metrics, Metric{
+			Name:   metricLastSuccess,
+			Labels: labels,
+			Value:  float64(result.LastSuccess.UnixNano()) / 1e9,
+		})
+	}
+
+	return metrics
 }
 
 func copyLabels(labels prometheus.Labels) prometheus.Labels {
@@ -214,16 +834,12 @@ func withLabelValues(labels prometheus.Labels, key, value string) prometheus.Lab
 	return labelCopy
 }
 
-var illegalLabelCharactersFirst = regexp.MustCompile(`[^a-zA-Z_]`)
-var illegalLabelCharacters = regexp.MustCompile(`[^a-zA-Z0-9_]`)
-
-func sanitizeLabelKey(labelKey string) string {
-	firstCharacter := illegalLabelCharactersFirst.ReplaceAllString(labelKey[:1], "_")
-
-	var rest string
-	if len(labelKey) > 1 {
-		rest = illegalLabelCharacters.ReplaceAllString(labelKey[1:], "_")
+// endSpan marks the span as failed if there is an error and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
 
-	return firstCharacter + rest
+	span.End()
 }
@@ -0,0 +1,338 @@
+package evaluation_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/exporter"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+// newRegistry serves the given tags of the repository lib/redis and counts tag listings.
+func newRegistry(t *testing.T, tagNames ...string) (string, *atomic.Int32) {
+	references := make([]string, 0, len(tagNames))
+	for _, tag := range tagNames {
+		references = append(references, "lib/redis:"+tag)
+	}
+
+	return evaluationtest.Registry(t, references...)
+}
+
+type recordingObserver struct {
+	mutex   sync.Mutex
+	updated []evaluation.Result
+}
+
+func (r *recordingObserver) ResultUpdated(result evaluation.Result) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.updated = append(r.updated, result)
+}
+
+func (r *recordingObserver) ResultRemoved(evaluation.Result) {}
+
+func (r *recordingObserver) results() []evaluation.Result {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	updated := r.updated
+	r.updated = nil
+
+	return updated
+}
+
+func container(name, image string, annotations map[string]string) clients.ContainerImage {
+	return clients.ContainerImage{
+		Action:      clients.ContainerImageAdded,
+		Name:        name,
+		Image:       image,
+		Annotations: annotations,
+	}
+}
+
+func TestEvaluator_SharesEvaluationsPerImage(t *testing.T) {
+	host, listings := newRegistry(t, "7.2.4", "7.2.5", "8.0.0")
+
+	client := make(evaluationtest.Client, 10)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		client <- container(name, host+"/lib/redis:7.2.4", nil)
+	}
+
+	client <- container("pinned", host+"/lib/redis:7.2.4", map[string]string{version.AnnotationPinMode: "major"})
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 4, CacheTTL: time.Hour})
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	require.Equal(t, int32(2), listings.Load(), "one listing per image and policy")
+
+	results := evaluator.Results()
+	require.Len(t, results, 5)
+
+	for _, result := range results {
+		require.NoError(t, result.Err)
+
+		if result.ContainerImage.Name == "pinned" {
+			require.Equal(t, "7.2.5", result.Latest)
+		} else {
+			require.Equal(t, "8.0.0", result.Latest)
+		}
+	}
+}
+
+func TestEvaluator_WithoutCache(t *testing.T) {
+	host, listings := newRegistry(t, "7.2.4", "8.0.0")
+
+	client := make(evaluationtest.Client, 10)
+	client <- container("a", host+"/lib/redis:7.2.4", nil)
+	client <- container("b", host+"/lib/redis:7.2.4", nil)
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1})
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	require.Equal(t, int32(2), listings.Load())
+}
+
+func TestEvaluator_FansOutFreshEvaluations(t *testing.T) {
+	host, listings := newRegistry(t, "7.2.4", "7.2.5")
+
+	client := make(evaluationtest.Client)
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1, CacheTTL: 200 * time.Millisecond})
+
+	observer := &recordingObserver{}
+	evaluator.AddObserver(observer)
+
+	done := make(chan error)
+
+	go func() {
+		done <- evaluator.Run(context.Background())
+	}()
+
+	client <- container("a", host+"/lib/redis:7.2.4", nil)
+	client <- container("b", host+"/lib/redis:7.2.4", nil)
+
+	require.Eventually(t, func() bool {
+		return len(evaluator.Results()) == 2
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, int32(1), listings.Load())
+
+	observer.results()
+	time.Sleep(250 * time.Millisecond)
+
+	client <- container("a", host+"/lib/redis:7.2.4", nil)
+	close(client)
+	require.NoError(t, <-done)
+
+	require.Equal(t, int32(2), listings.Load())
+
+	updated := observer.results()
+	require.Len(t, updated, 2)
+	require.ElementsMatch(t, []string{"a", "b"}, []string{updated[0].ContainerImage.Name, updated[1].ContainerImage.Name})
+	require.Equal(t, updated[0].CheckedAt, updated[1].CheckedAt)
+}
+
+func TestEvaluator_CheckStatus(t *testing.T) {
+	host, _ := newRegistry(t, "7.2.4", "8.0.0", "latest")
+
+	client := make(evaluationtest.Client, 10)
+	client <- container("ok", host+"/lib/redis:7.2.4", nil)
+	client <- container("missing", host+"/lib/valkey:7.2.4", nil)
+	client <- container("latest", host+"/lib/redis:latest", nil)
+	client <- container("digest", host+"/lib/redis@sha256:0000000000000000000000000000000000000000000000000000000000000000", nil)
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1})
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(exporter.NewCollector(evaluator)))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	reasons := map[string]string{}
+	lastSuccess := map[string]float64{}
+
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			switch family.GetName() {
+			case "container_image_check_status":
+				require.Equal(t, 1.0, metric.GetGauge().GetValue())
+				reasons[labels["container"]] = labels["reason"]
+			case "container_image_last_success_timestamp_seconds":
+				lastSuccess[labels["container"]] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+
+	require.Equal(t, map[string]string{
+		"ok":      evaluation.ReasonOK,
+		"missing": evaluation.ReasonNotFound,
+		"latest":  evaluation.ReasonUnparseableTag,
+		"digest":  evaluation.ReasonDigestOnly,
+	}, reasons)
+
+	require.Len(t, lastSuccess, 1)
+	require.InDelta(t, float64(time.Now().Unix()), lastSuccess["ok"], 60)
+}
+
+func TestEvaluator_MetricSchema(t *testing.T) {
+	host, _ := newRegistry(t, "7.2.4", "8.0.0")
+
+	replica := func(pod string) clients.ContainerImage {
+		containerImage := container("default/"+pod+"/redis", host+"/lib/redis:7.2.4", nil)
+		containerImage.Labels = map[string]string{"app.kubernetes.io/name": "cache", "pod-template-hash": pod}
+		containerImage.Metadata = map[string]interface{}{
+			clients.MetadataNamespace: "default",
+			clients.MetadataWorkload:  k8s.Workload{Kind: "Deployment", Namespace: "default", Name: "cache"},
+			clients.MetadataContainer: "redis",
+		}
+
+		return containerImage
+	}
+
+	client := make(evaluationtest.Client, 10)
+	client <- replica("cache-7d4b9-abcde")
+	client <- replica("cache-7d4b9-fghij")
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1, Labels: []string{"app.kubernetes.io/name"}})
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(exporter.NewCollector(evaluator)))
+
+	expected := fmt.Sprintf(`
+# HELP container_image_outdated Exports how many major, minor or patch versions a image in a podspec is outdated
+# TYPE container_image_outdated gauge
+container_image_outdated{app_kubernetes_io_name="cache",container="redis",image="%[1]s/lib/redis:7.2.4",namespace="default",repository="%[1]s/lib/redis",stale="false",type="major",workload="Deployment/cache"} 1
+container_image_outdated{app_kubernetes_io_name="cache",container="redis",image="%[1]s/lib/redis:7.2.4",namespace="default",repository="%[1]s/lib/redis",stale="false",type="minor",workload="Deployment/cache"} 0
+container_image_outdated{app_kubernetes_io_name="cache",container="redis",image="%[1]s/lib/redis:7.2.4",namespace="default",repository="%[1]s/lib/redis",stale="false",type="patch",workload="Deployment/cache"} 0
+# HELP container_image_check_status Is 1 for the reason the last check of an image failed, or ok if it succeeded
+# TYPE container_image_check_status gauge
+container_image_check_status{app_kubernetes_io_name="cache",container="redis",image="%[1]s/lib/redis:7.2.4",namespace="default",reason="ok",repository="%[1]s/lib/redis",workload="Deployment/cache"} 1
+`, host)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "container_image_outdated", "container_image_check_status"))
+}
+
+func TestNewEvaluator_LabelCollisions(t *testing.T) {
+	for _, labels := range [][]string{
+		{"namespace"},
+		{"app.kubernetes.io/name", "app_kubernetes_io/name"},
+		{"__name__"},
+		{""},
+	} {
+		_, err := evaluation.NewEvaluator(evaluation.Config{Labels: labels}, nil, nil, nil, slog.Default())
+		require.Error(t, err, "%v", labels)
+	}
+
+	require.ErrorIs(t, evaluation.ValidateLabels([]string{"team", "Team", "type"}), evaluation.ErrLabelCollision)
+	require.NoError(t, evaluation.ValidateLabels([]string{"team", "Team", "app.kubernetes.io/name"}))
+}
+
+func TestEvaluator_InfoMetric(t *testing.T) {
+	host, _ := newRegistry(t, "7.2.4", "7.2.5", "7.4.0", "8.0.0")
+
+	const digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+	running := container("running", host+"/lib/redis:7.2.4", nil)
+	running.Metadata = map[string]interface{}{clients.MetadataDigest: digest}
+
+	client := make(evaluationtest.Client, 10)
+	client <- running
+	client <- container("pinned", host+"/lib/redis:8.0.0@"+digest, nil)
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1})
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(exporter.NewCollector(evaluator)))
+
+	expected := fmt.Sprintf(`
+# HELP container_image_info Is 1 for every evaluated image, labelled with its current tag and digest and the newest tags to update to
+# TYPE container_image_info gauge
+container_image_info{container="pinned",current_digest="%[2]s",current_tag="8.0.0",image="%[1]s/lib/redis:8.0.0@%[2]s",latest_major="",latest_minor="",latest_patch="",namespace="",registry="%[1]s",repository="%[1]s/lib/redis",workload=""} 1
+container_image_info{container="running",current_digest="%[2]s",current_tag="7.2.4",image="%[1]s/lib/redis:7.2.4",latest_major="8.0.0",latest_minor="7.4.0",latest_patch="7.2.5",namespace="",registry="%[1]s",repository="%[1]s/lib/redis",workload=""} 1
+`, host, digest)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "container_image_info"))
+}
+
+func TestEvaluator_SharesEvaluationsPerCredentials(t *testing.T) {
+	host, _ := newRegistry(t, "7.2.4", "8.0.0")
+
+	target, err := url.Parse("http://" + host)
+	require.NoError(t, err)
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	// The registry requires credentials, like a private repository
+	private := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if username, password, ok := request.BasicAuth(); !ok || username != "bot" || password != "secret" {
+			writer.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			http.Error(writer, "unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		proxy.ServeHTTP(writer, request)
+	}))
+	t.Cleanup(private.Close)
+
+	privateHost := strings.TrimPrefix(private.URL, "http://")
+
+	authorized := container("prod/web/redis", privateHost+"/lib/redis:7.2.4", nil)
+	authorized.Metadata = map[string]interface{}{
+		clients.MetadataDockerKeychain: tags.KeychainFromAuthConfigs(map[string]authn.AuthConfig{
+			privateHost: {Username: "bot", Password: "secret"},
+		}),
+	}
+
+	client := make(evaluationtest.Client, 2)
+	client <- authorized
+	client <- container("dev/web/redis", privateHost+"/lib/redis:7.2.4", nil)
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 2, CacheTTL: time.Hour})
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	results := map[string]evaluation.Result{}
+	for _, result := range evaluator.Results() {
+		results[result.ContainerImage.Name] = result
+	}
+
+	require.NoError(t, results["prod/web/redis"].Err)
+	require.Equal(t, "8.0.0", results["prod/web/redis"].Latest)
+
+	require.Error(t, results["dev/web/redis"].Err)
+	require.Empty(t, results["dev/web/redis"].Latest)
+}
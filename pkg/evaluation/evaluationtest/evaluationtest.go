@@ -0,0 +1,107 @@
+// Package evaluationtest provides fixtures for testing consumers of the evaluator.
+package evaluationtest
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+// Result returns the result of a container in a pod of the workload, named and labeled like the kubernetes
+// source does.
+func Result(workload k8s.Workload, pod, container, image string, evaluated version.Evaluation) evaluation.Result {
+	return evaluation.Result{
+		ContainerImage: clients.ContainerImage{
+			Name:  workload.Namespace + "/" + pod + "/" + container,
+			Image: image,
+			Metadata: map[string]interface{}{
+				clients.MetadataNamespace: workload.Namespace,
+				clients.MetadataWorkload:  workload,
+				clients.MetadataContainer: container,
+			},
+		},
+		Evaluation: evaluated,
+	}
+}
+
+// Client is a source whose container images are sent on the channel. Closing the channel ends the evaluator's run.
+type Client chan clients.ContainerImage
+
+func (c Client) Listener(context.Context) (<-chan clients.ContainerImage, error) {
+	return c, nil
+}
+
+// Registry serves a random image under every reference, like lib/redis:7.2.4. It returns the registry host and
+// counts tag listings.
+func Registry(t *testing.T, references ...string) (string, *atomic.Int32) {
+	t.Helper()
+
+	listings := &atomic.Int32{}
+	handler := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.Path, "/tags/list") {
+			listings.Add(1)
+		}
+
+		handler.ServeHTTP(writer, request)
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	image, err := random.Image(64, 1)
+	require.NoError(t, err)
+
+	for _, reference := range references {
+		ref, err := name.NewTag(host+"/"+reference, name.Insecure)
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(ref, image))
+	}
+
+	return host, listings
+}
+
+// UnreachableHost returns the address of a registry that refuses connections.
+func UnreachableHost(t *testing.T) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	return server.Listener.Addr().String()
+}
+
+// NewEvaluator returns an evaluator of the client's container images. It isn't run yet.
+func NewEvaluator(t *testing.T, client evaluation.ContainerClient, config evaluation.Config) *evaluation.Evaluator {
+	t.Helper()
+
+	tagLister, err := tags.NewTagLister(&tags.DockerConfigKeychain{})
+	require.NoError(t, err)
+
+	checker, err := version.NewChecker()
+	require.NoError(t, err)
+
+	evaluator, err := evaluation.NewEvaluator(config, tagLister, checker, client, slog.Default())
+	require.NoError(t, err)
+
+	return evaluator
+}
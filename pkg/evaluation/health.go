@@ -0,0 +1,103 @@
+package evaluation
+
+import (
+	"sync"
+	"time"
+)
+
+// Health describes whether the evaluator is making progress.
+type Health struct {
+	// Running is set while Run consumes container images
+	Running bool
+
+	// Synced is set once the container sources are listening, e.g. after the informer caches are synced
+	Synced bool
+
+	// Initialized is set once every container image the sources found at the start was handled
+	Initialized bool
+
+	// Finished is set once one-shot sources reported every container image
+	Finished bool
+
+	// Stopped is set if watching sources closed their channel, so changes are no longer picked up
+	Stopped bool
+
+	// Evaluated counts the container images checked successfully since the start
+	Evaluated int
+
+	// LastEvent is when the last container image was received from the sources
+	LastEvent time.Time
+
+	// OldestInFlight is when the longest running worker started on its current container image, zero if all are idle
+	OldestInFlight time.Time
+
+	// RegistryFailures counts the tag listings that failed since the last successful one
+	RegistryFailures int
+
+	// LastRegistrySuccess is when tags were last listed successfully
+	LastRegistrySuccess time.Time
+}
+
+type healthState struct {
+	mutex    sync.Mutex
+	health   Health
+	inFlight map[int]time.Time
+}
+
+// Health returns the current progress of the evaluator.
+func (e *Evaluator) Health() Health {
+	e.healthState.mutex.Lock()
+	defer e.healthState.mutex.Unlock()
+
+	health := e.healthState.health
+
+	for _, started := range e.healthState.inFlight {
+		if health.OldestInFlight.IsZero() || started.Before(health.OldestInFlight) {
+			health.OldestInFlight = started
+		}
+	}
+
+	return health
+}
+
+func (h *healthState) update(fn func(health *Health)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	fn(&h.health)
+}
+
+func (h *healthState) started(worker int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	now := time.Now()
+
+	h.health.LastEvent = now
+	h.inFlight[worker] = now
+}
+
+func (h *healthState) done(worker int, evaluated bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delete(h.inFlight, worker)
+
+	if evaluated {
+		h.health.Evaluated++
+	}
+}
+
+func (h *healthState) listed(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if err != nil {
+		h.health.RegistryFailures++
+
+		return
+	}
+
+	h.health.RegistryFailures = 0
+	h.health.LastRegistrySuccess = time.Now()
+}
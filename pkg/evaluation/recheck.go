@@ -0,0 +1,79 @@
+package evaluation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Recheck evaluates every container running the image again right away, bypassing the cached evaluation. References
+// are compared after normalizing, so nginx:1.25 matches index.docker.io/library/nginx:1.25. Results restored from a
+// snapshot that the sources didn't report again are skipped. It returns the new results, none if no container runs
+// the image.
+func (e *Evaluator) Recheck(ctx context.Context, image string) []Result {
+	e.metricsMutex.RLock()
+
+	var matching []Result
+
+	for containerName, result := range e.results {
+		// restored results may belong to containers that are gone and carry no credentials, the sources refresh them
+		if _, restored := e.restored[containerName]; restored {
+			continue
+		}
+
+		if sameImage(result.ContainerImage.Image, image) {
+			matching = append(matching, result)
+		}
+	}
+
+	e.metricsMutex.RUnlock()
+
+	e.imagesMutex.Lock()
+	for _, result := range matching {
+		for key := range e.images {
+			if strings.HasPrefix(key, result.ContainerImage.Image+" ") {
+				delete(e.images, key)
+			}
+		}
+	}
+	e.imagesMutex.Unlock()
+
+	for _, result := range matching {
+		err := e.handleContainerImageAdded(ctx, result.ContainerImage, true)
+		if err != nil {
+			e.logger.Warn("error rechecking image", "name", result.ContainerImage.Name, "image", image, "error", err)
+		}
+	}
+
+	e.metricsMutex.RLock()
+	defer e.metricsMutex.RUnlock()
+
+	rechecked := make([]Result, 0, len(matching))
+
+	for _, result := range matching {
+		if updated, ok := e.results[result.ContainerImage.Name]; ok {
+			rechecked = append(rechecked, updated)
+		}
+	}
+
+	return rechecked
+}
+
+func sameImage(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	refA, err := name.ParseReference(a)
+	if err != nil {
+		return false
+	}
+
+	refB, err := name.ParseReference(b)
+	if err != nil {
+		return false
+	}
+
+	return refA.Name() == refB.Name()
+}
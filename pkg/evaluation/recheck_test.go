@@ -0,0 +1,110 @@
+package evaluation_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/state"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+func TestEvaluator_RecheckRemovedContainer(t *testing.T) {
+	host, _ := newRegistry(t, "7.2.4", "8.0.0")
+
+	target, err := url.Parse("http://" + host)
+	require.NoError(t, err)
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	// Once blocking, tag listings wait until the container was removed
+	var blocking atomic.Bool
+
+	listing := make(chan struct{})
+	release := make(chan struct{})
+
+	slow := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if blocking.Load() && strings.HasSuffix(request.URL.Path, "/tags/list") {
+			listing <- struct{}{}
+			<-release
+		}
+
+		proxy.ServeHTTP(writer, request)
+	}))
+	t.Cleanup(slow.Close)
+
+	image := strings.TrimPrefix(slow.URL, "http://") + "/lib/redis:7.2.4"
+
+	client := make(evaluationtest.Client)
+	observer := &recordingObserver{}
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1, CacheTTL: time.Hour})
+	evaluator.AddObserver(observer)
+
+	done := make(chan error)
+	go func() { done <- evaluator.Run(context.Background()) }()
+
+	client <- container("redis", image, nil)
+
+	require.Eventually(t, func() bool { return len(evaluator.Results()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, observer.results(), 1)
+
+	blocking.Store(true)
+
+	rechecked := make(chan []evaluation.Result)
+	go func() { rechecked <- evaluator.Recheck(context.Background(), image) }()
+
+	<-listing
+
+	removed := container("redis", image, nil)
+	removed.Action = clients.ContainerImageRemoved
+	client <- removed
+
+	require.Eventually(t, func() bool { return len(evaluator.Results()) == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	close(release)
+
+	require.Empty(t, <-rechecked)
+	require.Empty(t, evaluator.Results())
+	require.Empty(t, evaluator.Series())
+	require.Empty(t, observer.results(), "the removed container isn't reported again")
+
+	close(client)
+	require.NoError(t, <-done)
+}
+
+func TestEvaluator_RecheckRestoredContainer(t *testing.T) {
+	host, listings := newRegistry(t, "7.2.4", "8.0.0")
+	image := host + "/lib/redis:7.2.4"
+
+	// The container was deleted while the exporter was down, so the sources don't report it again
+	client := make(evaluationtest.Client)
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1})
+	evaluator.Restore(&state.Snapshot{Results: []state.Result{{
+		Name:       "deleted",
+		Image:      image,
+		Evaluation: version.Evaluation{Current: "7.2.4", Major: 1, Latest: "8.0.0"},
+		CheckedAt:  time.Now().Add(-time.Hour),
+	}}})
+
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	require.Empty(t, evaluator.Recheck(context.Background(), image))
+	require.Zero(t, listings.Load())
+
+	require.Equal(t, 1, evaluator.ExpireRestored())
+	require.Empty(t, evaluator.Results())
+}
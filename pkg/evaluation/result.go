@@ -0,0 +1,89 @@
+package evaluation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+// Result is the outcome of the last check of a container image.
+type Result struct {
+	ContainerImage clients.ContainerImage
+
+	version.Evaluation
+
+	// Policy is the name of the ImageUpdatePolicy applied to the image, empty if none matched
+	Policy string
+
+	// Err is set if the image could not be checked
+	Err error
+
+	// Stale is set for results restored from a saved state and not refreshed yet, and for results evaluated with the
+	// last known tags because the registry could not be reached
+	Stale bool
+
+	// Reason is why the last check failed or fell back to the last known tags, ReasonOK if it succeeded
+	Reason string
+
+	// LastSuccess is when the image was last checked with fresh tags, zero if it never was
+	LastSuccess time.Time
+
+	CheckedAt time.Time
+}
+
+// Severity is the most significant version difference of a result.
+func (r Result) Severity() Severity {
+	switch {
+	case r.Err != nil:
+		return SeverityNone
+	case r.Major != 0:
+		return SeverityMajor
+	case r.Minor != 0:
+		return SeverityMinor
+	case r.Patch != 0:
+		return SeverityPatch
+	}
+
+	return SeverityNone
+}
+
+type Severity int
+
+const (
+	SeverityNone Severity = iota
+	SeverityPatch
+	SeverityMinor
+	SeverityMajor
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityNone:
+		return "none"
+	case SeverityPatch:
+		return "patch"
+	case SeverityMinor:
+		return "minor"
+	case SeverityMajor:
+		return "major"
+	}
+
+	return "unknown"
+}
+
+func ParseSeverity(severity string) (Severity, error) {
+	switch severity {
+	case "none":
+		return SeverityNone, nil
+	case "patch":
+		return SeverityPatch, nil
+	case "minor":
+		return SeverityMinor, nil
+	case "major":
+		return SeverityMajor, nil
+	}
+
+	return SeverityNone, fmt.Errorf("unknown severity %q, expected one of [none, patch, minor, major]", severity)
+}
@@ -0,0 +1,288 @@
+package evaluation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+)
+
+// ErrLabelCollision is returned if allowed source labels end up with the same metric label name after sanitizing
+var ErrLabelCollision = errors.New("label name collision")
+
+// Labels of the container metrics
+const (
+	labelNamespace  = "namespace"
+	labelWorkload   = "workload"
+	labelContainer  = "container"
+	labelImage      = "image"
+	labelRepository = "repository"
+	labelType       = "type"
+	labelPolicy     = "policy"
+	labelReason     = "reason"
+
+	labelCurrentTag    = "current_tag"
+	labelCurrentDigest = "current_digest"
+	labelLatestPatch   = "latest_patch"
+	labelLatestMinor   = "latest_minor"
+	labelLatestMajor   = "latest_major"
+	labelRegistry      = "registry"
+
+	// labelStale marks metrics of stale results
+	labelStale = "stale"
+)
+
+// baseLabels are the labels of every container metric, metricLabels the labels specific to each metric.
+var (
+	baseLabels   = []string{labelNamespace, labelWorkload, labelContainer, labelImage, labelRepository}
+	metricLabels = map[string][]string{
+		metricOutdated:        {labelType, labelStale},
+		metricPolicyViolation: {labelPolicy, labelStale},
+		metricCheckStatus:     {labelReason},
+		metricLastSuccess:     {},
+		metricInfo: {
+			labelCurrentTag, labelCurrentDigest, labelLatestPatch, labelLatestMinor, labelLatestMajor, labelRegistry,
+		},
+	}
+)
+
+// labelSchema fixes the label names of every container metric: the base labels, the labels of the metric and the
+// allowed source labels.
+type labelSchema struct {
+	sourceLabels []sourceLabel
+	labelNames   map[string][]string
+	descs        map[string]*prometheus.Desc
+}
+
+// sourceLabel maps a label of the container source to its metric label name.
+type sourceLabel struct {
+	key  string
+	name string
+}
+
+// ValidateLabels checks that the source labels can be copied to the metrics without colliding with each other or the
+// labels of the exporter.
+func ValidateLabels(allowedLabels []string) error {
+	_, err := newLabelSchema(allowedLabels)
+
+	return err
+}
+
+// LabelNames returns the names of every label of the container metrics with the allowed source labels, sorted.
+func LabelNames(allowedLabels []string) ([]string, error) {
+	schema, err := newLabelSchema(allowedLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+
+	for _, labelNames := range schema.labelNames {
+		for _, labelName := range labelNames {
+			names[labelName] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for labelName := range names {
+		sorted = append(sorted, labelName)
+	}
+
+	sort.Strings(sorted)
+
+	return sorted, nil
+}
+
+func newLabelSchema(allowedLabels []string) (*labelSchema, error) {
+	reserved := map[string]bool{}
+
+	for _, label := range baseLabels {
+		reserved[label] = true
+	}
+
+	for _, labels := range metricLabels {
+		for _, label := range labels {
+			reserved[label] = true
+		}
+	}
+
+	schema := &labelSchema{
+		labelNames: map[string][]string{},
+		descs:      map[string]*prometheus.Desc{},
+	}
+
+	sourceLabelNames := make([]string, 0, len(allowedLabels))
+	seen := map[string]string{}
+
+	for _, key := range allowedLabels {
+		if key == "" {
+			return nil, errors.New("empty source label")
+		}
+
+		labelName := sanitizeLabelKey(key)
+
+		if strings.HasPrefix(labelName, "__") {
+			return nil, fmt.Errorf("source label %q: metric label names starting with __ are reserved", key)
+		}
+
+		if reserved[labelName] {
+			return nil, fmt.Errorf("%w: source label %q would replace the %s label", ErrLabelCollision, key, labelName)
+		}
+
+		if other, ok := seen[labelName]; ok {
+			return nil, fmt.Errorf("%w: source labels %q and %q are both exported as %s", ErrLabelCollision, other, key, labelName)
+		}
+
+		seen[labelName] = key
+		schema.sourceLabels = append(schema.sourceLabels, sourceLabel{key: key, name: labelName})
+		sourceLabelNames = append(sourceLabelNames, labelName)
+	}
+
+	for metricName, labels := range metricLabels {
+		labelNames := make([]string, 0, len(baseLabels)+len(labels)+len(sourceLabelNames))
+		labelNames = append(labelNames, baseLabels...)
+		labelNames = append(labelNames, labels...)
+		labelNames = append(labelNames, sourceLabelNames...)
+
+		schema.labelNames[metricName] = labelNames
+		schema.descs[metricName] = prometheus.NewDesc(metricName, metricHelp[metricName], labelNames, nil)
+	}
+
+	return schema, nil
+}
+
+// labels returns the base labels and allowed source labels of a container. Missing values are empty.
+func (s *labelSchema) labels(containerImage clients.ContainerImage) prometheus.Labels {
+	container, ok := containerImage.Metadata[clients.MetadataContainer].(string)
+	if !ok {
+		container = containerImage.Name
+	}
+
+	labels := prometheus.Labels{
+		labelContainer: container,
+		labelImage:     containerImage.Image,
+	}
+
+	labels[labelNamespace], _ = containerImage.Metadata[clients.MetadataNamespace].(string)
+
+	if workload, ok := containerImage.Metadata[clients.MetadataWorkload].(k8s.Workload); ok {
+		labels[labelWorkload] = workload.Kind + "/" + workload.Name
+	}
+
+	if ref, err := name.ParseReference(containerImage.Image); err == nil {
+		labels[labelRepository] = ref.Context().Name()
+	}
+
+	for _, label := range s.sourceLabels {
+		labels[label.name] = containerImage.Labels[label.key]
+	}
+
+	return labels
+}
+
+// relabel replaces the base and source labels of a metric, e.g. one saved with an older schema, and keeps the
+// labels specific to the metric.
+func (s *labelSchema) relabel(metric Metric, labels prometheus.Labels) Metric {
+	relabeled := copyLabels(labels)
+
+	for _, label := range metricLabels[metric.Name] {
+		relabeled[label] = metric.Labels[label]
+	}
+
+	metric.Labels = relabeled
+
+	return metric
+}
+
+func (s *labelSchema) describe(descs chan<- *prometheus.Desc) {
+	for _, desc := range s.descs {
+		descs <- desc
+	}
+}
+
+// labelValues returns the values of a metric's labels in the order of its descriptor, false for unknown metrics.
+func (s *labelSchema) labelValues(metric Metric) ([]string, bool) {
+	labelNames, ok := s.labelNames[metric.Name]
+	if !ok {
+		return nil, false
+	}
+
+	values := make([]string, 0, len(labelNames))
+	for _, labelName := range labelNames {
+		values = append(values, metric.Labels[labelName])
+	}
+
+	return values, true
+}
+
+// deduplicate completes the labels of every metric and merges series with the same labels, e.g. of replicas running
+// the same image, into one with the highest value. Unknown metrics are dropped.
+func (s *labelSchema) deduplicate(metrics []Metric) []Metric {
+	deduplicated := map[string]*Metric{}
+	order := make([]string, 0, len(metrics))
+
+	for _, metric := range metrics {
+		values, ok := s.labelValues(metric)
+		if !ok {
+			continue
+		}
+
+		key := metric.Name + "\xff" + strings.Join(values, "\xff")
+
+		existing, ok := deduplicated[key]
+		if !ok {
+			labels := prometheus.Labels{}
+			for i, labelName := range s.labelNames[metric.Name] {
+				labels[labelName] = values[i]
+			}
+
+			deduplicated[key] = &Metric{Name: metric.Name, Labels: labels, Value: metric.Value}
+			order = append(order, key)
+
+			continue
+		}
+
+		existing.Value = max(existing.Value, metric.Value)
+	}
+
+	result := make([]Metric, 0, len(order))
+
+	for _, key := range order {
+		result = append(result, *deduplicated[key])
+	}
+
+	return result
+}
+
+// constMetrics turns deduplicated metrics into gauges.
+func (s *labelSchema) constMetrics(metrics []Metric) []prometheus.Metric {
+	result := make([]prometheus.Metric, 0, len(metrics))
+
+	for _, metric := range metrics {
+		values, _ := s.labelValues(metric)
+		result = append(result, prometheus.MustNewConstMetric(s.descs[metric.Name], prometheus.GaugeValue, metric.Value, values...))
+	}
+
+	return result
+}
+
+var illegalLabelCharactersFirst = regexp.MustCompile(`[^a-zA-Z_]`)
+var illegalLabelCharacters = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func sanitizeLabelKey(labelKey string) string {
+	firstCharacter := illegalLabelCharactersFirst.ReplaceAllString(labelKey[:1], "_")
+
+	var rest string
+	if len(labelKey) > 1 {
+		rest = illegalLabelCharacters.ReplaceAllString(labelKey[1:], "_")
+	}
+
+	return firstCharacter + rest
+}
@@ -0,0 +1,162 @@
+package evaluation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/state"
+)
+
+// Snapshot returns the current results, their metrics and the last tag listings for saving.
+func (e *Evaluator) Snapshot() *state.Snapshot {
+	e.metricsMutex.RLock()
+	defer e.metricsMutex.RUnlock()
+
+	snapshot := &state.Snapshot{
+		SavedAt:  time.Now(),
+		Results:  make([]state.Result, 0, len(e.results)),
+		TagLists: e.tagLister.TagLists(),
+	}
+
+	for name, result := range e.results {
+		saved := state.Result{
+			Name:        name,
+			Image:       result.ContainerImage.Image,
+			Labels:      result.ContainerImage.Labels,
+			Annotations: result.ContainerImage.Annotations,
+			Evaluation:  result.Evaluation,
+			Policy:      result.Policy,
+			Reason:      result.Reason,
+			CheckedAt:   result.CheckedAt,
+			LastSuccess: result.LastSuccess,
+		}
+
+		// Filtered tags are only useful for debugging a single image and would make up most of the snapshot
+		saved.Evaluation.Filtered = nil
+
+		if result.Err != nil {
+			saved.Err = result.Err.Error()
+		}
+
+		metadata := result.ContainerImage.Metadata
+		saved.Namespace, _ = metadata[clients.MetadataNamespace].(string)
+		saved.Container, _ = metadata[clients.MetadataContainer].(string)
+		saved.File, _ = metadata[clients.MetadataFile].(string)
+		saved.Line, _ = metadata[clients.MetadataLine].(int)
+		saved.Column, _ = metadata[clients.MetadataColumn].(int)
+
+		if workload, ok := metadata[clients.MetadataWorkload].(k8s.Workload); ok {
+			saved.Workload = &workload
+		}
+
+		for _, metric := range e.metrics[name] {
+			saved.Metrics = append(saved.Metrics, state.Metric{
+				Name:   metric.Name,
+				Labels: metric.Labels,
+				Value:  metric.Value,
+			})
+		}
+
+		snapshot.Results = append(snapshot.Results, saved)
+	}
+
+	return snapshot
+}
+
+// Restore adds the results of a saved snapshot as stale results, so metrics are available before the images are
+// checked again. Results that were already refreshed are kept. Restore must be called before Run.
+func (e *Evaluator) Restore(snapshot *state.Snapshot) {
+	e.tagLister.RestoreTagLists(snapshot.TagLists)
+
+	e.metricsMutex.Lock()
+	defer e.metricsMutex.Unlock()
+
+	for _, saved := range snapshot.Results {
+		if _, ok := e.results[saved.Name]; ok {
+			continue
+		}
+
+		metadata := map[string]interface{}{}
+
+		for key, value := range map[string]any{
+			clients.MetadataNamespace: saved.Namespace,
+			clients.MetadataContainer: saved.Container,
+			clients.MetadataFile:      saved.File,
+		} {
+			if value != "" {
+				metadata[key] = value
+			}
+		}
+
+		if saved.File != "" {
+			metadata[clients.MetadataLine] = saved.Line
+			metadata[clients.MetadataColumn] = saved.Column
+		}
+
+		if saved.Workload != nil {
+			metadata[clients.MetadataWorkload] = *saved.Workload
+		}
+
+		result := Result{
+			ContainerImage: clients.ContainerImage{
+				Action:      clients.ContainerImageAdded,
+				Name:        saved.Name,
+				Metadata:    metadata,
+				Labels:      saved.Labels,
+				Annotations: saved.Annotations,
+				Image:       saved.Image,
+			},
+			Evaluation:  saved.Evaluation,
+			Policy:      saved.Policy,
+			Stale:       true,
+			Reason:      saved.Reason,
+			CheckedAt:   saved.CheckedAt,
+			LastSuccess: saved.LastSuccess,
+		}
+
+		if saved.Err != "" {
+			result.Err = errors.New(saved.Err)
+		}
+
+		labels := e.schema.labels(result.ContainerImage)
+
+		metrics := make([]Metric, 0, len(saved.Metrics))
+		for _, metric := range saved.Metrics {
+			restored := e.schema.relabel(Metric{
+				Name:   metric.Name,
+				Labels: metric.Labels,
+				Value:  metric.Value,
+			}, labels)
+			restored.Labels[labelStale] = "true"
+
+			metrics = append(metrics, restored)
+		}
+
+		e.results[saved.Name] = result
+		e.metrics[saved.Name] = metrics
+		e.restored[saved.Name] = struct{}{}
+	}
+}
+
+// ExpireRestored removes restored results that were not refreshed yet, e.g. because their container is gone. If
+// the refresh failed, the failed result is kept and only the restored metrics are removed.
+func (e *Evaluator) ExpireRestored() int {
+	e.metricsMutex.Lock()
+	defer e.metricsMutex.Unlock()
+
+	expired := len(e.restored)
+
+	for name := range e.restored {
+		if result, ok := e.results[name]; !ok || result.Stale {
+			delete(e.results, name)
+		}
+
+		delete(e.metrics, name)
+	}
+
+	e.restored = map[string]struct{}{}
+
+	return expired
+}
@@ -0,0 +1,90 @@
+package evaluation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/state"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+// unreachableImage returns an image reference of a registry that refuses connections.
+func unreachableImage(t *testing.T) (string, string) {
+	repository := evaluationtest.UnreachableHost(t) + "/lib/redis"
+
+	return repository, repository + ":7.2.4"
+}
+
+func TestEvaluator_RestoreFailedRefresh(t *testing.T) {
+	_, image := unreachableImage(t)
+
+	client := make(evaluationtest.Client, 1)
+	client <- container("redis", image, nil)
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1})
+	evaluator.Restore(&state.Snapshot{Results: []state.Result{{
+		Name:       "redis",
+		Image:      image,
+		Evaluation: version.Evaluation{Current: "7.2.4", Major: 1, Latest: "8.0.0"},
+		CheckedAt:  time.Now().Add(-time.Hour),
+		Metrics:    []state.Metric{{Name: "container_image_outdated", Labels: map[string]string{"type": "major"}, Value: 1}},
+	}}})
+
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	outdated := func() int {
+		count := 0
+
+		for _, series := range evaluator.Series() {
+			if series.Name == "container_image_outdated" {
+				count++
+			}
+		}
+
+		return count
+	}
+
+	// The failed refresh keeps the restored metrics until they expire
+	require.Equal(t, 1, outdated())
+	require.Equal(t, 1, evaluator.ExpireRestored())
+	require.Zero(t, outdated())
+
+	results := evaluator.Results()
+	require.Len(t, results, 1)
+	require.Error(t, results[0].Err)
+}
+
+func TestEvaluator_LastTagsFallback(t *testing.T) {
+	repository, image := unreachableImage(t)
+
+	for _, fallback := range []bool{false, true} {
+		client := make(evaluationtest.Client, 1)
+		client <- container("redis", image, nil)
+		close(client)
+
+		evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1, LastTagsFallback: fallback})
+		evaluator.Restore(&state.Snapshot{TagLists: map[string]tags.TagList{
+			repository: {Tags: []string{"7.2.4", "7.2.5"}, ListedAt: time.Now().Add(-time.Hour)},
+		}})
+
+		require.NoError(t, evaluator.Run(context.Background()))
+
+		results := evaluator.Results()
+		require.Len(t, results, 1)
+
+		if fallback {
+			require.NoError(t, results[0].Err)
+			require.True(t, results[0].Stale)
+			require.Equal(t, "7.2.5", results[0].Latest)
+		} else {
+			require.Error(t, results[0].Err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+// Reasons of container_image_check_status
+const (
+	ReasonOK             = "ok"
+	ReasonAuth           = "auth"
+	ReasonNotFound       = "not_found"
+	ReasonRateLimit      = "ratelimit"
+	ReasonUnparseableTag = "unparseable_tag"
+	ReasonDigestOnly     = "digest_only"
+	ReasonTimeout        = "timeout"
+	ReasonError          = "error"
+)
+
+// CheckReason classifies why an image could not be checked, ReasonOK for nil.
+func CheckReason(err error) string {
+	if err == nil {
+		return ReasonOK
+	}
+
+	if errors.Is(err, tags.ErrDigestOnly) {
+		return ReasonDigestOnly
+	}
+
+	if errors.Is(err, version.ErrUnparseableTag) {
+		return ReasonUnparseableTag
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ReasonTimeout
+	}
+
+	var transportErr *transport.Error
+	if !errors.As(err, &transportErr) {
+		return ReasonError
+	}
+
+	for _, diagnostic := range transportErr.Errors {
+		switch diagnostic.Code {
+		case transport.UnauthorizedErrorCode, transport.DeniedErrorCode:
+			return ReasonAuth
+		case transport.NameUnknownErrorCode, transport.ManifestUnknownErrorCode:
+			return ReasonNotFound
+		case transport.TooManyRequestsErrorCode:
+			return ReasonRateLimit
+		}
+	}
+
+	switch transportErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ReasonAuth
+	case http.StatusNotFound:
+		return ReasonNotFound
+	case http.StatusTooManyRequests:
+		return ReasonRateLimit
+	}
+
+	return ReasonError
+}
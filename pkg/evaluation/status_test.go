@@ -0,0 +1,39 @@
+package evaluation_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+func TestCheckReason(t *testing.T) {
+	for _, test := range []struct {
+		err    error
+		reason string
+	}{
+		{nil, evaluation.ReasonOK},
+		{&transport.Error{StatusCode: http.StatusUnauthorized}, evaluation.ReasonAuth},
+		{&transport.Error{StatusCode: http.StatusForbidden}, evaluation.ReasonAuth},
+		{&transport.Error{StatusCode: http.StatusBadRequest, Errors: []transport.Diagnostic{{Code: transport.DeniedErrorCode}}}, evaluation.ReasonAuth},
+		{&transport.Error{StatusCode: http.StatusNotFound}, evaluation.ReasonNotFound},
+		{&transport.Error{StatusCode: http.StatusNotFound, Errors: []transport.Diagnostic{{Code: transport.NameUnknownErrorCode}}}, evaluation.ReasonNotFound},
+		{&transport.Error{StatusCode: http.StatusTooManyRequests}, evaluation.ReasonRateLimit},
+		{fmt.Errorf("listing: %w", &transport.Error{StatusCode: http.StatusTooManyRequests}), evaluation.ReasonRateLimit},
+		{&transport.Error{StatusCode: http.StatusInternalServerError}, evaluation.ReasonError},
+		{fmt.Errorf("%w: nginx@sha256:0123", tags.ErrDigestOnly), evaluation.ReasonDigestOnly},
+		{fmt.Errorf("%w: malformed version: latest", version.ErrUnparseableTag), evaluation.ReasonUnparseableTag},
+		{fmt.Errorf("Get \"https://ghcr.io/v2/\": %w", context.DeadlineExceeded), evaluation.ReasonTimeout},
+		{errors.New("connection refused"), evaluation.ReasonError},
+	} {
+		require.Equal(t, test.reason, evaluation.CheckReason(test.err), "%v", test.err)
+	}
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"log/slog"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/report"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/transition"
+)
+
+const (
+	Component = "outdated-image-exporter"
+
+	ReasonImageOutdated = "ImageOutdated"
+)
+
+// Recorder records a Warning event on the workload of a container whenever its image falls further behind: from
+// up to date to outdated, or from a patch or minor difference to a larger one. Replicas share their state, so a
+// workload gets one event per transition of its most outdated replica.
+type Recorder struct {
+	recorder    record.EventRecorder
+	initialized func() bool
+	logger      *slog.Logger
+	tracker     *transition.Tracker
+}
+
+// NewRecorder records events with the recorder. Until initialized reports that the images found at the start were
+// evaluated, results only seed the state of their workloads, so a restart doesn't record an event for every outdated
+// workload again. A nil initialized records every change.
+func NewRecorder(recorder record.EventRecorder, initialized func() bool, logger *slog.Logger) *Recorder {
+	return &Recorder{
+		recorder:    recorder,
+		initialized: initialized,
+		logger:      logger,
+		tracker:     transition.NewTracker(),
+	}
+}
+
+// NewBroadcastRecorder creates an event recorder sending events to the cluster. The correlator aggregates similar
+// events and limits each workload to a burst of events, refilled every five minutes.
+func NewBroadcastRecorder(clientset kubernetes.Interface) (record.EventRecorder, func()) {
+	broadcaster := record.NewBroadcasterWithCorrelatorOptions(record.CorrelatorOptions{
+		BurstSize: 10,
+		QPS:       1. / 300.,
+	})
+
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: clientset.CoreV1().Events(""),
+	})
+
+	return broadcaster.NewRecorder(scheme.Scheme, coreV1.EventSource{Component: Component}), broadcaster.Shutdown
+}
+
+func (r *Recorder) ResultUpdated(result evaluation.Result) {
+	if result.Err != nil {
+		return
+	}
+
+	workload, ok := result.ContainerImage.Metadata[clients.MetadataWorkload].(k8s.Workload)
+	if !ok {
+		return
+	}
+
+	previous, highest := r.tracker.Update(result)
+
+	if r.initialized != nil && !r.initialized() {
+		return
+	}
+
+	severity := highest.Severity()
+	if severity <= previous {
+		return
+	}
+
+	container, _ := result.ContainerImage.Metadata[clients.MetadataContainer].(string)
+
+	r.logger.Info("recording outdated image event", "workload", transition.Key(result), "previous", previous.String(), "severity", severity.String())
+
+	r.recorder.Eventf(workload.ObjectReference(), coreV1.EventTypeWarning, ReasonImageOutdated,
+		"Container %s: %s, newest allowed tag is %s", container, report.Describe(highest), highest.Latest)
+}
+
+func (r *Recorder) ResultRemoved(result evaluation.Result) {
+	if _, ok := result.ContainerImage.Metadata[clients.MetadataWorkload].(k8s.Workload); !ok {
+		return
+	}
+
+	r.tracker.Remove(result)
+}
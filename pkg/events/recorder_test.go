@@ -0,0 +1,117 @@
+package events_test
+
+import (
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/events"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+var web = k8s.Workload{
+	APIVersion: "apps/v1",
+	Kind:       "Deployment",
+	Namespace:  "prod",
+	Name:       "web",
+}
+
+func TestRecorder(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder := events.NewRecorder(fakeRecorder, nil, slog.Default())
+
+	upToDate := version.Evaluation{}
+	minor := version.Evaluation{Minor: 1, Latest: "1.25.3"}
+	major := version.Evaluation{Major: 1, Latest: "2.0.0"}
+
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", upToDate))
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", minor))
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-2", "nginx", "nginx:1.24.0", minor))
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", minor))
+
+	failed := evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", version.Evaluation{})
+	failed.Err = errors.New("unauthorized")
+	recorder.ResultUpdated(failed)
+
+	recorder.ResultRemoved(evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", minor))
+	recorder.ResultRemoved(evaluationtest.Result(web, "web-2", "nginx", "nginx:1.24.0", minor))
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-3", "nginx", "nginx:1.24.0", minor))
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-3", "nginx", "nginx:1.24.0", major))
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-3", "nginx", "nginx:1.24.0", upToDate))
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-3", "nginx", "nginx:1.24.0", minor))
+
+	close(fakeRecorder.Events)
+
+	var recorded []string
+	for event := range fakeRecorder.Events {
+		recorded = append(recorded, event)
+	}
+
+	require.Equal(t, []string{
+		"Warning ImageOutdated Container nginx: nginx:1.24.0 is 1 minor version(s) behind, newest allowed tag is 1.25.3",
+		"Warning ImageOutdated Container nginx: nginx:1.24.0 is 1 major version(s) behind, newest allowed tag is 2.0.0",
+		"Warning ImageOutdated Container nginx: nginx:1.24.0 is 1 minor version(s) behind, newest allowed tag is 1.25.3",
+	}, recorded)
+}
+
+func TestRecorder_Rollout(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder := events.NewRecorder(fakeRecorder, nil, slog.Default())
+
+	upToDate := version.Evaluation{}
+	major := version.Evaluation{Major: 1, Latest: "2.0.0"}
+
+	// The new replica is up to date while the old one is still running
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", major))
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-2", "nginx", "nginx:2.0.0", upToDate))
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", major))
+	recorder.ResultRemoved(evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", major))
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-2", "nginx", "nginx:2.0.0", upToDate))
+
+	close(fakeRecorder.Events)
+
+	var recorded []string
+	for event := range fakeRecorder.Events {
+		recorded = append(recorded, event)
+	}
+
+	require.Equal(t, []string{
+		"Warning ImageOutdated Container nginx: nginx:1.24.0 is 1 major version(s) behind, newest allowed tag is 2.0.0",
+	}, recorded)
+}
+
+func TestRecorder_Seeding(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+
+	var initialized atomic.Bool
+
+	recorder := events.NewRecorder(fakeRecorder, initialized.Load, slog.Default())
+
+	minor := version.Evaluation{Minor: 1, Latest: "1.25.3"}
+	major := version.Evaluation{Major: 1, Latest: "2.0.0"}
+
+	// After a restart, the workload was already outdated
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", minor))
+
+	initialized.Store(true)
+
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", minor))
+	recorder.ResultUpdated(evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", major))
+
+	close(fakeRecorder.Events)
+
+	var recorded []string
+	for event := range fakeRecorder.Events {
+		recorded = append(recorded, event)
+	}
+
+	require.Equal(t, []string{
+		"Warning ImageOutdated Container nginx: nginx:1.24.0 is 1 major version(s) behind, newest allowed tag is 2.0.0",
+	}, recorded)
+}
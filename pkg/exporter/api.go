@@ -0,0 +1,431 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+
+	// minRecheckInterval limits rechecks of the same image, so clients can't use up the rate limit of a registry
+	minRecheckInterval = time.Minute
+)
+
+type evaluationResponse struct {
+	Severity    string        `json:"severity"`
+	Current     string        `json:"current,omitempty"`
+	Major       int64         `json:"major"`
+	Minor       int64         `json:"minor"`
+	Patch       int64         `json:"patch"`
+	Latest      string        `json:"latest,omitempty"`
+	LatestMajor string        `json:"latestMajor,omitempty"`
+	LatestMinor string        `json:"latestMinor,omitempty"`
+	LatestPatch string        `json:"latestPatch,omitempty"`
+	Candidates  []string      `json:"candidates,omitempty"`
+	Filtered    []filteredTag `json:"filtered,omitempty"`
+	Policy      string        `json:"policy,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Reason      string        `json:"reason,omitempty"`
+	Stale       bool          `json:"stale"`
+	CheckedAt   time.Time     `json:"checkedAt"`
+	LastSuccess *time.Time    `json:"lastSuccess,omitempty"`
+}
+
+type filteredTag struct {
+	Tag    string `json:"tag"`
+	Reason string `json:"reason"`
+}
+
+type containerResponse struct {
+	Name       string            `json:"name"`
+	Namespace  string            `json:"namespace,omitempty"`
+	Workload   string            `json:"workload,omitempty"`
+	Container  string            `json:"container,omitempty"`
+	Image      string            `json:"image"`
+	Repository string            `json:"repository,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+
+	evaluationResponse
+}
+
+// imageResponse is the evaluation of an image reference with one policy, shared by the containers running it.
+type imageResponse struct {
+	Image      string   `json:"image"`
+	Repository string   `json:"repository,omitempty"`
+	Containers []string `json:"containers"`
+
+	evaluationResponse
+}
+
+type listResponse[T any] struct {
+	Items  []T `json:"items"`
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// resultFilter selects results by the query parameters namespace, severity and repository. Each can be given
+// several times, a result has to match one of the values of every given parameter.
+type resultFilter struct {
+	namespaces   []string
+	severities   []string
+	repositories []string
+}
+
+// page is a window of a sorted list given by the query parameters offset and limit.
+type page struct {
+	offset int
+	limit  int
+}
+
+// apiHandler serves the evaluation results as JSON.
+type apiHandler struct {
+	evaluator *evaluation.Evaluator
+
+	// rechecked is when each image was last rechecked
+	rechecked     map[string]time.Time
+	rechecksMutex sync.Mutex
+}
+
+// NewAPIHandler serves the results of the evaluator as JSON under /api/v1.
+func NewAPIHandler(evaluator *evaluation.Evaluator) http.Handler {
+	handler := &apiHandler{
+		evaluator: evaluator,
+		rechecked: map[string]time.Time{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/containers", handler.containers)
+	mux.HandleFunc("GET /api/v1/images", handler.images)
+	mux.HandleFunc("POST /api/v1/images/{path...}", handler.recheck)
+
+	return mux
+}
+
+func (a *apiHandler) containers(writer http.ResponseWriter, request *http.Request) {
+	filter, window, err := parseQuery(request)
+	if err != nil {
+		writeJSON(writer, http.StatusBadRequest, errorResponse{Error: err.Error()})
+
+		return
+	}
+
+	var items []containerResponse
+
+	for _, result := range a.evaluator.Results() {
+		if filter.matchesResult(result) && filter.matchesSeverity(result.Severity().String()) {
+			items = append(items, newContainerResponse(result))
+		}
+	}
+
+	writeJSON(writer, http.StatusOK, paginate(items, window))
+}
+
+func (a *apiHandler) images(writer http.ResponseWriter, request *http.Request) {
+	filter, window, err := parseQuery(request)
+	if err != nil {
+		writeJSON(writer, http.StatusBadRequest, errorResponse{Error: err.Error()})
+
+		return
+	}
+
+	var results []evaluation.Result
+
+	for _, result := range a.evaluator.Results() {
+		if filter.matchesResult(result) {
+			results = append(results, result)
+		}
+	}
+
+	var items []imageResponse
+
+	for _, image := range groupByImage(results) {
+		if filter.matchesSeverity(image.Severity) {
+			items = append(items, image)
+		}
+	}
+
+	writeJSON(writer, http.StatusOK, paginate(items, window))
+}
+
+// recheck serves POST /api/v1/images/{ref}/recheck. The reference may contain slashes, escaped or not. An image is
+// rechecked at most once per minRecheckInterval, further requests get 429.
+func (a *apiHandler) recheck(writer http.ResponseWriter, request *http.Request) {
+	image, ok := strings.CutSuffix(request.PathValue("path"), "/recheck")
+	if !ok || image == "" {
+		writeJSON(writer, http.StatusNotFound, errorResponse{Error: "not found"})
+
+		return
+	}
+
+	key := image
+	if ref, err := name.ParseReference(image); err == nil {
+		key = ref.Name()
+	}
+
+	if wait := a.startRecheck(key); wait > 0 {
+		writer.Header().Set("Retry-After", strconv.Itoa(int(wait.Round(time.Second).Seconds())))
+		writeJSON(writer, http.StatusTooManyRequests, errorResponse{
+			Error: fmt.Sprintf("%s was rechecked recently, retry in %s", image, wait.Round(time.Second)),
+		})
+
+		return
+	}
+
+	results := a.evaluator.Recheck(request.Context(), image)
+	if len(results) == 0 {
+		// nothing was checked, so it doesn't count
+		a.rechecksMutex.Lock()
+		delete(a.rechecked, key)
+		a.rechecksMutex.Unlock()
+
+		writeJSON(writer, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("no container runs %s", image)})
+
+		return
+	}
+
+	items := groupByImage(results)
+
+	writeJSON(writer, http.StatusOK, listResponse[imageResponse]{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+// startRecheck records a recheck of the image. It returns how long to wait instead if the image was rechecked less
+// than minRecheckInterval ago.
+func (a *apiHandler) startRecheck(key string) time.Duration {
+	a.rechecksMutex.Lock()
+	defer a.rechecksMutex.Unlock()
+
+	now := time.Now()
+
+	for image, rechecked := range a.rechecked {
+		if now.Sub(rechecked) >= minRecheckInterval {
+			delete(a.rechecked, image)
+		}
+	}
+
+	if rechecked, ok := a.rechecked[key]; ok {
+		return minRecheckInterval - now.Sub(rechecked)
+	}
+
+	a.rechecked[key] = now
+
+	return 0
+}
+
+func parseQuery(request *http.Request) (resultFilter, page, error) {
+	query := request.URL.Query()
+
+	filter := resultFilter{
+		namespaces: query["namespace"],
+	}
+
+	for _, severity := range query["severity"] {
+		parsed, err := evaluation.ParseSeverity(severity)
+		if err != nil {
+			return resultFilter{}, page{}, err
+		}
+
+		filter.severities = append(filter.severities, parsed.String())
+	}
+
+	for _, repository := range query["repository"] {
+		parsed, err := name.NewRepository(repository)
+		if err != nil {
+			return resultFilter{}, page{}, fmt.Errorf("invalid repository %q: %w", repository, err)
+		}
+
+		filter.repositories = append(filter.repositories, parsed.Name())
+	}
+
+	window := page{limit: defaultPageLimit}
+
+	var err error
+
+	if offset := query.Get("offset"); offset != "" {
+		window.offset, err = strconv.Atoi(offset)
+		if err != nil || window.offset < 0 {
+			return resultFilter{}, page{}, fmt.Errorf("invalid offset %q", offset)
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		window.limit, err = strconv.Atoi(limit)
+		if err != nil || window.limit < 1 || window.limit > maxPageLimit {
+			return resultFilter{}, page{}, fmt.Errorf("invalid limit %q, expected 1 to %d", limit, maxPageLimit)
+		}
+	}
+
+	return filter, window, nil
+}
+
+func (f resultFilter) matchesResult(result evaluation.Result) bool {
+	namespace, _ := result.ContainerImage.Metadata[clients.MetadataNamespace].(string)
+	if len(f.namespaces) > 0 && !slices.Contains(f.namespaces, namespace) {
+		return false
+	}
+
+	return len(f.repositories) == 0 || slices.Contains(f.repositories, repositoryOf(result.ContainerImage.Image))
+}
+
+func (f resultFilter) matchesSeverity(severity string) bool {
+	return len(f.severities) == 0 || slices.Contains(f.severities, severity)
+}
+
+func paginate[T any](items []T, window page) listResponse[T] {
+	response := listResponse[T]{
+		Items:  []T{},
+		Total:  len(items),
+		Offset: window.offset,
+		Limit:  window.limit,
+	}
+
+	if window.offset < len(items) {
+		response.Items = items[window.offset:min(window.offset+window.limit, len(items))]
+	}
+
+	return response
+}
+
+// groupByImage merges the results of containers running the same image with the same policy. The most recent
+// check of the group is reported.
+func groupByImage(results []evaluation.Result) []imageResponse {
+	type group struct {
+		latest     evaluation.Result
+		containers []string
+	}
+
+	groups := map[string]*group{}
+
+	for _, result := range results {
+		key := result.ContainerImage.Image + "\x00" + result.Policy
+
+		existing, ok := groups[key]
+		if !ok {
+			groups[key] = &group{latest: result, containers: []string{result.ContainerImage.Name}}
+
+			continue
+		}
+
+		existing.containers = append(existing.containers, result.ContainerImage.Name)
+
+		if result.CheckedAt.After(existing.latest.CheckedAt) {
+			existing.latest = result
+		}
+	}
+
+	images := make([]imageResponse, 0, len(groups))
+
+	for _, group := range groups {
+		sort.Strings(group.containers)
+
+		images = append(images, imageResponse{
+			Image:              group.latest.ContainerImage.Image,
+			Repository:         repositoryOf(group.latest.ContainerImage.Image),
+			Containers:         group.containers,
+			evaluationResponse: newEvaluationResponse(group.latest),
+		})
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		if images[i].Image != images[j].Image {
+			return images[i].Image < images[j].Image
+		}
+
+		return images[i].Policy < images[j].Policy
+	})
+
+	return images
+}
+
+func newContainerResponse(result evaluation.Result) containerResponse {
+	metadata := result.ContainerImage.Metadata
+
+	response := containerResponse{
+		Name:               result.ContainerImage.Name,
+		Image:              result.ContainerImage.Image,
+		Repository:         repositoryOf(result.ContainerImage.Image),
+		Labels:             result.ContainerImage.Labels,
+		evaluationResponse: newEvaluationResponse(result),
+	}
+
+	response.Namespace, _ = metadata[clients.MetadataNamespace].(string)
+	response.Container, _ = metadata[clients.MetadataContainer].(string)
+
+	if workload, ok := metadata[clients.MetadataWorkload].(k8s.Workload); ok {
+		response.Workload = workload.Kind + "/" + workload.Name
+	}
+
+	return response
+}
+
+func newEvaluationResponse(result evaluation.Result) evaluationResponse {
+	response := evaluationResponse{
+		Severity:    result.Severity().String(),
+		Current:     result.Current,
+		Major:       result.Major,
+		Minor:       result.Minor,
+		Patch:       result.Patch,
+		Latest:      result.Latest,
+		LatestMajor: result.LatestMajor,
+		LatestMinor: result.LatestMinor,
+		LatestPatch: result.LatestPatch,
+		Candidates:  result.Candidates,
+		Policy:      result.Policy,
+		Reason:      result.Reason,
+		Stale:       result.Stale,
+		CheckedAt:   result.CheckedAt,
+	}
+
+	for _, filtered := range result.Filtered {
+		response.Filtered = append(response.Filtered, filteredTag{Tag: filtered.Tag, Reason: filtered.Reason})
+	}
+
+	if result.Err != nil {
+		response.Error = result.Err.Error()
+	}
+
+	if !result.LastSuccess.IsZero() {
+		lastSuccess := result.LastSuccess
+		response.LastSuccess = &lastSuccess
+	}
+
+	return response
+}
+
+func repositoryOf(image string) string {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return ""
+	}
+
+	return ref.Context().Name()
+}
+
+func writeJSON(writer http.ResponseWriter, status int, body any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+
+	_ = json.NewEncoder(writer).Encode(body)
+}
@@ -0,0 +1,160 @@
+package exporter_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/exporter"
+)
+
+type listResponse struct {
+	Items []struct {
+		Name       string   `json:"name"`
+		Image      string   `json:"image"`
+		Namespace  string   `json:"namespace"`
+		Severity   string   `json:"severity"`
+		Latest     string   `json:"latest"`
+		Candidates []string `json:"candidates"`
+		Containers []string `json:"containers"`
+	} `json:"items"`
+	Total int `json:"total"`
+}
+
+// newEvaluator evaluates redis and nginx containers in two namespaces against a local registry. It returns the
+// registry host and counts tag listings after the first evaluation.
+func newEvaluator(t *testing.T) (*evaluation.Evaluator, string, *atomic.Int32) {
+	host, listings := evaluationtest.Registry(t, "lib/redis:7.2.4", "lib/redis:8.0.0", "lib/nginx:1.25.4")
+
+	client := make(evaluationtest.Client, 10)
+
+	for _, container := range []struct{ namespace, pod, container, image string }{
+		{"default", "web", "redis", "lib/redis:7.2.4"},
+		{"prod", "api", "redis", "lib/redis:7.2.4"},
+		{"prod", "api", "nginx", "lib/nginx:1.25.4"},
+	} {
+		client <- clients.ContainerImage{
+			Action: clients.ContainerImageAdded,
+			Name:   container.namespace + "/" + container.pod + "/" + container.container,
+			Image:  host + "/" + container.image,
+			Metadata: map[string]interface{}{
+				clients.MetadataNamespace: container.namespace,
+				clients.MetadataContainer: container.container,
+			},
+		}
+	}
+
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1})
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	listings.Store(0)
+
+	return evaluator, host, listings
+}
+
+func newAPI(t *testing.T) (*httptest.Server, string, *atomic.Int32) {
+	evaluator, host, listings := newEvaluator(t)
+
+	server := httptest.NewServer(exporter.NewAPIHandler(evaluator))
+	t.Cleanup(server.Close)
+
+	return server, host, listings
+}
+
+func get(t *testing.T, url string, expectedStatus int) listResponse {
+	response, err := http.Get(url)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, expectedStatus, response.StatusCode)
+	require.Equal(t, "application/json", response.Header.Get("Content-Type"))
+
+	var list listResponse
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&list))
+
+	return list
+}
+
+func TestAPI_Containers(t *testing.T) {
+	server, host, _ := newAPI(t)
+
+	all := get(t, server.URL+"/api/v1/containers", http.StatusOK)
+	require.Equal(t, 3, all.Total)
+	require.Equal(t, "default/web/redis", all.Items[0].Name)
+	require.Equal(t, "major", all.Items[0].Severity)
+	require.Equal(t, []string{"8.0.0"}, all.Items[0].Candidates)
+
+	prod := get(t, server.URL+"/api/v1/containers?namespace=prod&severity=major", http.StatusOK)
+	require.Equal(t, 1, prod.Total)
+	require.Equal(t, "prod/api/redis", prod.Items[0].Name)
+
+	nginx := get(t, server.URL+"/api/v1/containers?repository="+url.QueryEscape(host+"/lib/nginx"), http.StatusOK)
+	require.Equal(t, 1, nginx.Total)
+	require.Equal(t, "none", nginx.Items[0].Severity)
+
+	page := get(t, server.URL+"/api/v1/containers?limit=1&offset=2", http.StatusOK)
+	require.Equal(t, 3, page.Total)
+	require.Len(t, page.Items, 1)
+	require.Equal(t, "prod/api/redis", page.Items[0].Name)
+
+	require.Empty(t, get(t, server.URL+"/api/v1/containers?offset=10", http.StatusOK).Items)
+
+	for _, query := range []string{"severity=critical", "limit=0", "limit=5000", "offset=-1", "repository=UPPER"} {
+		get(t, server.URL+"/api/v1/containers?"+query, http.StatusBadRequest)
+	}
+}
+
+func TestAPI_Images(t *testing.T) {
+	server, host, _ := newAPI(t)
+
+	images := get(t, server.URL+"/api/v1/images", http.StatusOK)
+	require.Equal(t, 2, images.Total)
+	require.Equal(t, host+"/lib/nginx:1.25.4", images.Items[0].Image)
+	require.Equal(t, host+"/lib/redis:7.2.4", images.Items[1].Image)
+	require.Equal(t, []string{"default/web/redis", "prod/api/redis"}, images.Items[1].Containers)
+	require.Equal(t, "8.0.0", images.Items[1].Latest)
+
+	outdated := get(t, server.URL+"/api/v1/images?severity=major&severity=minor&namespace=default", http.StatusOK)
+	require.Equal(t, 1, outdated.Total)
+	require.Equal(t, []string{"default/web/redis"}, outdated.Items[0].Containers)
+}
+
+func TestAPI_Recheck(t *testing.T) {
+	server, host, listings := newAPI(t)
+
+	post := func(path string) *http.Response {
+		response, err := http.Post(server.URL+path, "", nil)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+
+		return response
+	}
+
+	response := post("/api/v1/images/" + url.PathEscape(host+"/lib/redis:7.2.4") + "/recheck")
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, int32(2), listings.Load())
+
+	response = post("/api/v1/images/" + host + "/lib/nginx:1.25.4/recheck")
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, int32(3), listings.Load())
+
+	// The same image can't be rechecked again right away, however it is spelled
+	response = post("/api/v1/images/" + host + "/lib/redis:7.2.4/recheck")
+	require.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	require.Equal(t, "60", response.Header.Get("Retry-After"))
+	require.Equal(t, int32(3), listings.Load())
+
+	require.Equal(t, http.StatusNotFound, post("/api/v1/images/"+host+"/lib/postgres:16/recheck").StatusCode)
+	require.Equal(t, http.StatusNotFound, post("/api/v1/images/"+host+"/lib/redis:7.2.4").StatusCode)
+}
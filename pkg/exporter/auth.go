@@ -0,0 +1,257 @@
+package exporter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Access controls who may use an endpoint of the server.
+type Access string
+
+const (
+	// AccessNone serves everyone
+	AccessNone Access = "none"
+
+	// AccessAuthenticated requires a verified client certificate or a bearer token accepted by a TokenReview
+	AccessAuthenticated Access = "authenticated"
+
+	// AccessRBAC additionally requires a SubjectAccessReview to allow the request path, e.g. get on /metrics
+	AccessRBAC Access = "rbac"
+)
+
+// ParseAccess accepts none, authenticated and rbac. Empty means none.
+func ParseAccess(access string) (Access, error) {
+	switch Access(access) {
+	case "", AccessNone:
+		return AccessNone, nil
+	case AccessAuthenticated, AccessRBAC:
+		return Access(access), nil
+	}
+
+	return AccessNone, fmt.Errorf("unsupported access %q, expected one of [none, authenticated, rbac]", access)
+}
+
+// defaultReviewTTL is how long TokenReviews and SubjectAccessReviews are cached
+const defaultReviewTTL = time.Minute
+
+// user is an authenticated client, from a client certificate or a TokenReview.
+type user struct {
+	name   string
+	uid    string
+	groups []string
+	extra  map[string]authorizationv1.ExtraValue
+}
+
+// Authorizer checks requests against the Kubernetes API, like kube-rbac-proxy. Clients authenticate with a client
+// certificate verified by the TLS config, its common name and organizations are the user and groups, or with a bearer
+// token checked by a TokenReview. Requests to rbac endpoints are authorized by a SubjectAccessReview of the path and
+// the verb of the method. Reviews are cached for a minute.
+type Authorizer struct {
+	client kubernetes.Interface
+	ttl    time.Duration
+	logger *slog.Logger
+
+	mutex     sync.Mutex
+	tokens    map[string]cachedReview[*user]
+	decisions map[string]cachedReview[bool]
+}
+
+type cachedReview[T any] struct {
+	value   T
+	expires time.Time
+}
+
+// NewAuthorizer reviews tokens and access with the client. A nil client only accepts client certificates and
+// rejects every rbac endpoint. Failed reviews are logged, clients only get a generic error.
+func NewAuthorizer(client kubernetes.Interface, logger *slog.Logger) *Authorizer {
+	return &Authorizer{
+		client:    client,
+		ttl:       defaultReviewTTL,
+		logger:    logger,
+		tokens:    map[string]cachedReview[*user]{},
+		decisions: map[string]cachedReview[bool]{},
+	}
+}
+
+// Protect serves the handler only to clients with the required access.
+func (a *Authorizer) Protect(access Access, handler http.Handler) http.Handler {
+	if access == "" || access == AccessNone {
+		return handler
+	}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requestUser, err := a.authenticate(request)
+		if err != nil {
+			a.logger.Error("error reviewing token", "path", request.URL.Path, "error", err)
+			writeJSON(writer, http.StatusInternalServerError, errorResponse{Error: "authentication failed"})
+
+			return
+		}
+
+		if requestUser == nil {
+			writer.Header().Set("WWW-Authenticate", `Bearer realm="outdated-image-exporter"`)
+			writeJSON(writer, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+
+			return
+		}
+
+		if access == AccessRBAC {
+			allowed, err := a.authorize(request, requestUser)
+			if err != nil {
+				a.logger.Error("error reviewing access", "user", requestUser.name, "path", request.URL.Path, "error", err)
+				writeJSON(writer, http.StatusInternalServerError, errorResponse{Error: "authorization failed"})
+
+				return
+			}
+
+			if !allowed {
+				writeJSON(writer, http.StatusForbidden, errorResponse{
+					Error: fmt.Sprintf("user %q may not %s %s", requestUser.name, verbOf(request.Method), request.URL.Path),
+				})
+
+				return
+			}
+		}
+
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+// authenticate returns the user of the request, nil if it has no valid credentials.
+func (a *Authorizer) authenticate(request *http.Request) (*user, error) {
+	if request.TLS != nil && len(request.TLS.VerifiedChains) > 0 && len(request.TLS.VerifiedChains[0]) > 0 {
+		subject := request.TLS.VerifiedChains[0][0].Subject
+
+		return &user{name: subject.CommonName, groups: subject.Organization}, nil
+	}
+
+	token, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+	if !ok || strings.TrimSpace(token) == "" || a.client == nil {
+		return nil, nil
+	}
+
+	key := sha256.Sum256([]byte(token))
+	cacheKey := hex.EncodeToString(key[:])
+
+	if cached, ok := lookup(a, a.tokens, cacheKey); ok {
+		return cached, nil
+	}
+
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(request.Context(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var reviewedUser *user
+
+	if review.Status.Authenticated {
+		reviewedUser = &user{
+			name:   review.Status.User.Username,
+			uid:    review.Status.User.UID,
+			groups: review.Status.User.Groups,
+			extra:  map[string]authorizationv1.ExtraValue{},
+		}
+
+		for key, value := range review.Status.User.Extra {
+			reviewedUser.extra[key] = authorizationv1.ExtraValue(value)
+		}
+	}
+
+	store(a, a.tokens, cacheKey, reviewedUser)
+
+	return reviewedUser, nil
+}
+
+func (a *Authorizer) authorize(request *http.Request, requestUser *user) (bool, error) {
+	if a.client == nil {
+		return false, nil
+	}
+
+	verb := verbOf(request.Method)
+	cacheKey := strings.Join([]string{requestUser.name, requestUser.uid, strings.Join(requestUser.groups, ","), verb, request.URL.Path}, "\x00")
+
+	if cached, ok := lookup(a, a.decisions, cacheKey); ok {
+		return cached, nil
+	}
+
+	review, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(request.Context(), &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   requestUser.name,
+			UID:    requestUser.uid,
+			Groups: requestUser.groups,
+			Extra:  requestUser.extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: request.URL.Path,
+				Verb: verb,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	store(a, a.decisions, cacheKey, review.Status.Allowed)
+
+	return review.Status.Allowed, nil
+}
+
+func lookup[T any](a *Authorizer, cache map[string]cachedReview[T], key string) (T, bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	cached, ok := cache[key]
+	if !ok || time.Now().After(cached.expires) {
+		var empty T
+
+		return empty, false
+	}
+
+	return cached.value, true
+}
+
+// store caches a review and drops expired ones.
+func store[T any](a *Authorizer, cache map[string]cachedReview[T], key string, value T) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	now := time.Now()
+
+	for cachedKey, cached := range cache {
+		if now.After(cached.expires) {
+			delete(cache, cachedKey)
+		}
+	}
+
+	cache[key] = cachedReview[T]{value: value, expires: now.Add(a.ttl)}
+}
+
+// verbOf maps the request method to the verb checked by RBAC for non-resource URLs.
+func verbOf(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return "get"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		return "delete"
+	}
+
+	return strings.ToLower(method)
+}
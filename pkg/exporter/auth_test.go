@@ -0,0 +1,145 @@
+package exporter_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/exporter"
+)
+
+// newFakeCluster accepts the token "prometheus-token" for system:serviceaccount:monitoring:prometheus, which may get
+// /metrics. It counts the reviews created.
+func newFakeCluster() (*fake.Clientset, *int, *int) {
+	client := fake.NewSimpleClientset()
+	tokenReviews, accessReviews := 0, 0
+
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		tokenReviews++
+
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "prometheus-token" {
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{
+				Username: "system:serviceaccount:monitoring:prometheus",
+				Groups:   []string{"system:serviceaccounts"},
+			}
+		}
+
+		return true, review, nil
+	})
+
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		accessReviews++
+
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = (review.Spec.User == "system:serviceaccount:monitoring:prometheus" || review.Spec.User == "prometheus") &&
+			review.Spec.NonResourceAttributes.Path == "/metrics" &&
+			review.Spec.NonResourceAttributes.Verb == "get"
+
+		return true, review, nil
+	})
+
+	return client, &tokenReviews, &accessReviews
+}
+
+func serve(handler http.Handler, method, path, token string, connection *tls.ConnectionState) int {
+	request := httptest.NewRequest(method, path, nil)
+	request.TLS = connection
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	return recorder.Code
+}
+
+func TestAuthorizer_Protect(t *testing.T) {
+	client, tokenReviews, accessReviews := newFakeCluster()
+	authorizer := exporter.NewAuthorizer(client, slog.Default())
+
+	ok := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	open := authorizer.Protect(exporter.AccessNone, ok)
+	require.Equal(t, http.StatusOK, serve(open, http.MethodGet, "/metrics", "", nil))
+
+	authenticated := authorizer.Protect(exporter.AccessAuthenticated, ok)
+	require.Equal(t, http.StatusUnauthorized, serve(authenticated, http.MethodGet, "/api/v1/images", "", nil))
+	require.Equal(t, http.StatusUnauthorized, serve(authenticated, http.MethodGet, "/api/v1/images", "wrong-token", nil))
+	require.Equal(t, http.StatusOK, serve(authenticated, http.MethodGet, "/api/v1/images", "prometheus-token", nil))
+
+	rbac := authorizer.Protect(exporter.AccessRBAC, ok)
+	require.Equal(t, http.StatusOK, serve(rbac, http.MethodGet, "/metrics", "prometheus-token", nil))
+	require.Equal(t, http.StatusForbidden, serve(rbac, http.MethodPost, "/metrics", "prometheus-token", nil))
+	require.Equal(t, http.StatusForbidden, serve(rbac, http.MethodGet, "/api/v1/images", "prometheus-token", nil))
+
+	// Reviews are cached per token and per user, verb and path
+	require.Equal(t, http.StatusOK, serve(rbac, http.MethodGet, "/metrics", "prometheus-token", nil))
+	require.Equal(t, 2, *tokenReviews)
+	require.Equal(t, 3, *accessReviews)
+
+	connection := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+		{Subject: pkix.Name{CommonName: "prometheus", Organization: []string{"monitoring"}}},
+	}}}
+	require.Equal(t, http.StatusOK, serve(rbac, http.MethodGet, "/metrics", "", connection))
+	require.Equal(t, 2, *tokenReviews)
+}
+
+func TestAuthorizer_WithoutClient(t *testing.T) {
+	authorizer := exporter.NewAuthorizer(nil, slog.Default())
+	ok := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	connection := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+		{Subject: pkix.Name{CommonName: "prometheus"}},
+	}}}
+
+	require.Equal(t, http.StatusUnauthorized, serve(authorizer.Protect(exporter.AccessAuthenticated, ok), http.MethodGet, "/metrics", "token", nil))
+	require.Equal(t, http.StatusOK, serve(authorizer.Protect(exporter.AccessAuthenticated, ok), http.MethodGet, "/metrics", "", connection))
+	require.Equal(t, http.StatusForbidden, serve(authorizer.Protect(exporter.AccessRBAC, ok), http.MethodGet, "/metrics", "", connection))
+}
+
+func TestAuthorizer_ReviewFailed(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New(`tokenreviews.authentication.k8s.io is forbidden: User "system:serviceaccount:monitoring:exporter" cannot create`)
+	})
+
+	handler := exporter.NewAuthorizer(client, slog.Default()).Protect(exporter.AccessAuthenticated, http.NotFoundHandler())
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	request.Header.Set("Authorization", "Bearer token")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	require.JSONEq(t, `{"error": "authentication failed"}`, recorder.Body.String())
+}
+
+func TestParseAccess(t *testing.T) {
+	access, err := exporter.ParseAccess("")
+	require.NoError(t, err)
+	require.Equal(t, exporter.AccessNone, access)
+
+	access, err = exporter.ParseAccess("rbac")
+	require.NoError(t, err)
+	require.Equal(t, exporter.AccessRBAC, access)
+
+	_, err = exporter.ParseAccess("token")
+	require.Error(t, err)
+}
@@ -0,0 +1,350 @@
+package exporter
+
+import (
+	"embed"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var dashboardTemplate = template.Must(template.New("dashboard.html").Funcs(template.FuncMap{
+	"timestamp": func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+
+		return t.Format("2006-01-02 15:04:05")
+	},
+}).ParseFS(templates, "templates/dashboard.html"))
+
+// dashboardColumns can be sorted by, the first is the default
+var dashboardColumns = []struct {
+	key   string
+	label string
+}{
+	{"workload", "Workload"},
+	{"image", "Image"},
+	{"severity", "Lag"},
+	{"checked", "Checked"},
+}
+
+type dashboardPage struct {
+	Namespaces []dashboardNamespace
+	Columns    []dashboardColumn
+	Filter     dashboardFilter
+
+	// AllNamespaces are the namespaces to choose from in the filter
+	AllNamespaces []string
+
+	// Shown and Total count containers, so replicas sharing a row count separately
+	Shown       int
+	Total       int
+	GeneratedAt time.Time
+}
+
+type dashboardNamespace struct {
+	Name string
+	Rows []dashboardRow
+}
+
+// dashboardRow is a container of a workload. Replicas running the same image share a row.
+type dashboardRow struct {
+	Workload  string
+	Container string
+	Image     string
+	Current   string
+	Latest    string
+	LatestURL string
+	Major     int64
+	Minor     int64
+	Patch     int64
+	Severity  string
+	Error     string
+	Stale     bool
+	CheckedAt time.Time
+	Replicas  int
+
+	severity evaluation.Severity
+}
+
+type dashboardColumn struct {
+	Label      string
+	URL        string
+	Active     bool
+	Descending bool
+}
+
+type dashboardFilter struct {
+	Namespace string
+	Severity  string
+	Query     string
+	Sort      string
+	Desc      bool
+}
+
+// dashboardHandler renders the results as a read-only HTML page.
+type dashboardHandler struct {
+	evaluator *evaluation.Evaluator
+}
+
+// NewDashboardHandler serves an HTML page of the results, grouped by namespace and filtered and sorted by the query
+// parameters namespace, severity, q, sort and order.
+func NewDashboardHandler(evaluator *evaluation.Evaluator) http.Handler {
+	return &dashboardHandler{evaluator: evaluator}
+}
+
+func (d *dashboardHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+
+	filter := dashboardFilter{
+		Namespace: query.Get("namespace"),
+		Severity:  query.Get("severity"),
+		Query:     strings.TrimSpace(query.Get("q")),
+		Sort:      dashboardColumns[0].key,
+		Desc:      query.Get("order") == "desc",
+	}
+
+	for _, column := range dashboardColumns {
+		if query.Get("sort") == column.key {
+			filter.Sort = column.key
+		}
+	}
+
+	minSeverity, err := evaluation.ParseSeverity(filter.Severity)
+	if err != nil {
+		filter.Severity = ""
+		minSeverity = evaluation.SeverityNone
+	}
+
+	rows := map[string][]dashboardRow{}
+	allNamespaces := map[string]bool{}
+	total := 0
+
+	for _, row := range dashboardRows(d.evaluator.Results()) {
+		namespace := row.namespace
+		allNamespaces[namespace] = true
+		total += row.Replicas
+
+		if filter.Namespace != "" && namespace != filter.Namespace {
+			continue
+		}
+
+		if row.severity < minSeverity {
+			continue
+		}
+
+		if filter.Query != "" && !strings.Contains(row.Workload+" "+row.Container+" "+row.Image, filter.Query) {
+			continue
+		}
+
+		rows[namespace] = append(rows[namespace], row.dashboardRow)
+	}
+
+	page := dashboardPage{
+		Filter:      filter,
+		Total:       total,
+		GeneratedAt: time.Now(),
+	}
+
+	for namespace := range allNamespaces {
+		page.AllNamespaces = append(page.AllNamespaces, namespace)
+	}
+
+	sort.Strings(page.AllNamespaces)
+
+	for _, namespace := range page.AllNamespaces {
+		namespaceRows, ok := rows[namespace]
+		if !ok {
+			continue
+		}
+
+		sortRows(namespaceRows, filter.Sort, filter.Desc)
+
+		page.Namespaces = append(page.Namespaces, dashboardNamespace{Name: namespace, Rows: namespaceRows})
+		for _, row := range namespaceRows {
+			page.Shown += row.Replicas
+		}
+	}
+
+	for _, column := range dashboardColumns {
+		active := column.key == filter.Sort
+
+		link := url.Values{}
+		for key, value := range map[string]string{"namespace": filter.Namespace, "severity": filter.Severity, "q": filter.Query} {
+			if value != "" {
+				link.Set(key, value)
+			}
+		}
+
+		link.Set("sort", column.key)
+
+		if active && !filter.Desc {
+			link.Set("order", "desc")
+		}
+
+		page.Columns = append(page.Columns, dashboardColumn{
+			Label:      column.label,
+			URL:        "?" + link.Encode(),
+			Active:     active,
+			Descending: active && filter.Desc,
+		})
+	}
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	err = dashboardTemplate.Execute(writer, page)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type namespacedRow struct {
+	dashboardRow
+	namespace string
+}
+
+// dashboardRows merges the results of replicas into one row per workload, container and image. The most recent
+// check of the replicas is shown.
+func dashboardRows(results []evaluation.Result) []namespacedRow {
+	rows := map[string]*namespacedRow{}
+	keys := make([]string, 0, len(results))
+
+	for _, result := range results {
+		metadata := result.ContainerImage.Metadata
+
+		namespace, _ := metadata[clients.MetadataNamespace].(string)
+
+		workload := result.ContainerImage.Name
+		container, _ := metadata[clients.MetadataContainer].(string)
+
+		if owner, ok := metadata[clients.MetadataWorkload].(k8s.Workload); ok {
+			workload = owner.Kind + "/" + owner.Name
+		}
+
+		key := namespace + "\x00" + workload + "\x00" + container + "\x00" + result.ContainerImage.Image
+
+		if existing, ok := rows[key]; ok {
+			existing.Replicas++
+
+			if !result.CheckedAt.After(existing.CheckedAt) {
+				continue
+			}
+		}
+
+		row := namespacedRow{
+			namespace: namespace,
+			dashboardRow: dashboardRow{
+				Workload:  workload,
+				Container: container,
+				Image:     result.ContainerImage.Image,
+				Current:   result.Current,
+				Latest:    result.Latest,
+				Major:     result.Major,
+				Minor:     result.Minor,
+				Patch:     result.Patch,
+				Severity:  result.Severity().String(),
+				Stale:     result.Stale,
+				CheckedAt: result.CheckedAt,
+				Replicas:  1,
+				severity:  result.Severity(),
+			},
+		}
+
+		if result.Err != nil {
+			row.Error = result.Err.Error()
+		}
+
+		if result.Latest != "" {
+			row.LatestURL = tagURL(result.ContainerImage.Image, result.Latest)
+		}
+
+		if existing, ok := rows[key]; ok {
+			row.Replicas = existing.Replicas
+		} else {
+			keys = append(keys, key)
+		}
+
+		rows[key] = &row
+	}
+
+	sorted := make([]namespacedRow, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, *rows[key])
+	}
+
+	return sorted
+}
+
+func sortRows(rows []dashboardRow, column string, descending bool) {
+	less := func(a, b dashboardRow) bool {
+		switch column {
+		case "image":
+			return a.Image < b.Image
+		case "severity":
+			if a.severity != b.severity {
+				return a.severity < b.severity
+			}
+
+			if a.Major != b.Major {
+				return a.Major < b.Major
+			}
+
+			if a.Minor != b.Minor {
+				return a.Minor < b.Minor
+			}
+
+			return a.Patch < b.Patch
+		case "checked":
+			return a.CheckedAt.Before(b.CheckedAt)
+		}
+
+		if a.Workload != b.Workload {
+			return a.Workload < b.Workload
+		}
+
+		return a.Container < b.Container
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if descending {
+			return less(rows[j], rows[i])
+		}
+
+		return less(rows[i], rows[j])
+	})
+}
+
+// tagURL links to the page of a tag on the web interface of the registries that have one, empty for others.
+func tagURL(image, tag string) string {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return ""
+	}
+
+	repository := ref.Context().RepositoryStr()
+
+	switch ref.Context().RegistryStr() {
+	case name.DefaultRegistry:
+		if official, ok := strings.CutPrefix(repository, "library/"); ok {
+			return "https://hub.docker.com/_/" + official + "/tags?name=" + url.QueryEscape(tag)
+		}
+
+		return "https://hub.docker.com/r/" + repository + "/tags?name=" + url.QueryEscape(tag)
+	case "quay.io":
+		return "https://quay.io/repository/" + repository + "?tab=tags&tag=" + url.QueryEscape(tag)
+	}
+
+	return ""
+}
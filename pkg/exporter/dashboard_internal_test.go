@@ -0,0 +1,19 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagURL(t *testing.T) {
+	for image, expected := range map[string]string{
+		"nginx:1.25":                       "https://hub.docker.com/_/nginx/tags?name=1.26",
+		"bitnami/redis:7.2":                "https://hub.docker.com/r/bitnami/redis/tags?name=1.26",
+		"quay.io/prometheus/node-exporter": "https://quay.io/repository/prometheus/node-exporter?tab=tags&tag=1.26",
+		"ghcr.io/org/app:1.0":              "",
+		"registry.internal:5000/app:1.0":   "",
+	} {
+		require.Equal(t, expected, tagURL(image, "1.26"), image)
+	}
+}
@@ -0,0 +1,86 @@
+package exporter_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/exporter"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+func getPage(t *testing.T, url string) string {
+	response, err := http.Get(url)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/html; charset=utf-8", response.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestDashboard(t *testing.T) {
+	evaluator, _, _ := newEvaluator(t)
+
+	server := httptest.NewServer(exporter.NewDashboardHandler(evaluator))
+	defer server.Close()
+
+	page := getPage(t, server.URL+"/")
+	require.Contains(t, page, "3 of 3 containers")
+	require.Contains(t, page, "<h2>default</h2>")
+	require.Contains(t, page, "<h2>prod</h2>")
+	require.Contains(t, page, `<tr class="major">`)
+	require.Contains(t, page, "1 major, 0 minor, 0 patch")
+	require.Contains(t, page, "up to date")
+
+	major := getPage(t, server.URL+"/?severity=major&namespace=prod")
+	require.Contains(t, major, "1 of 3 containers")
+	require.NotContains(t, major, "<h2>default</h2>")
+	require.NotContains(t, major, "up to date")
+
+	sorted := getPage(t, server.URL+"/?namespace=prod&sort=image&order=desc")
+	require.Less(t, strings.Index(sorted, "lib/redis"), strings.Index(sorted, "lib/nginx"))
+	require.Contains(t, sorted, `href="?namespace=prod&amp;sort=image"`)
+
+	search := getPage(t, server.URL+"/?q=nginx")
+	require.Contains(t, search, "1 of 3 containers")
+}
+
+func TestDashboard_Replicas(t *testing.T) {
+	host := evaluationtest.UnreachableHost(t)
+	workload := k8s.Workload{Namespace: "prod", Kind: "Deployment", Name: "web"}
+
+	client := make(evaluationtest.Client, 3)
+
+	for _, pod := range []string{"web-1", "web-2", "web-3"} {
+		result := evaluationtest.Result(workload, pod, "app", host+"/app:1.0.0", version.Evaluation{})
+		result.ContainerImage.Action = clients.ContainerImageAdded
+
+		client <- result.ContainerImage
+	}
+
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1})
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	server := httptest.NewServer(exporter.NewDashboardHandler(evaluator))
+	defer server.Close()
+
+	page := getPage(t, server.URL+"/")
+	require.Contains(t, page, "3 of 3 containers")
+	require.Equal(t, 1, strings.Count(page, "Deployment/web"), "the replicas share a row")
+}
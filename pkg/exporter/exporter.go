@@ -2,6 +2,9 @@ package exporter
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
 	"net"
 	"net/http"
 	"time"
@@ -23,7 +26,7 @@ func NewCollector(evaluator *evaluation.Evaluator) *Collector {
 }
 
 func (c *Collector) Describe(descs chan<- *prometheus.Desc) {
-	return
+	c.evaluator.Describe(descs)
 }
 
 func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
@@ -32,24 +35,81 @@ func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
 	}
 }
 
-func RunServer(addr string) (func() error, error) {
+// ServerConfig configures the server of the metrics, API, dashboard and health endpoints.
+type ServerConfig struct {
+	Addr string
+
+	// TLS serves HTTPS instead of HTTP if set
+	TLS *tls.Config
+
+	// Access per endpoint, empty serves everyone
+	Access EndpointAccess
+
+	// Authorizer checks the endpoints that don't allow everyone
+	Authorizer *Authorizer
+}
+
+// EndpointAccess is who may use each group of endpoints.
+type EndpointAccess struct {
+	// Metrics is /metrics
+	Metrics Access
+
+	// API is /api/
+	API Access
+
+	// Dashboard is /
+	Dashboard Access
+}
+
+func (a EndpointAccess) protected() bool {
+	for _, access := range []Access{a.Metrics, a.API, a.Dashboard} {
+		if access != "" && access != AccessNone {
+			return true
+		}
+	}
+
+	return false
+}
+
+func RunServer(config ServerConfig, evaluator *evaluation.Evaluator) (func() error, error) {
+	authorizer := config.Authorizer
+	if authorizer == nil {
+		if config.Access.protected() {
+			return nil, errors.New("an authorizer is required for protected endpoints")
+		}
+
+		authorizer = NewAuthorizer(nil, slog.Default())
+	}
+
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.Handler())
-	mux.Handle("/ready", http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		writer.WriteHeader(200)
-	}))
+	mux.Handle("/metrics", authorizer.Protect(config.Access.Metrics, promhttp.Handler()))
+	mux.Handle("/api/", authorizer.Protect(config.Access.API, NewAPIHandler(evaluator)))
+	mux.Handle("GET /{$}", authorizer.Protect(config.Access.Dashboard, NewDashboardHandler(evaluator)))
+
+	// kubelet probes can't send credentials
+	mux.Handle("/ready", NewReadyHandler(evaluator))
+	mux.Handle("/healthz", NewHealthHandler(evaluator))
 
-	lis, err := net.Listen("tcp", addr)
+	lis, err := net.Listen("tcp", config.Addr)
 	if err != nil {
 		return nil, err
 	}
 
 	server := http.Server{
-		Handler: mux,
+		Handler:           mux,
+		TLSConfig:         config.TLS,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
 
 	go func() {
-		err := server.Serve(lis)
+		var err error
+
+		if config.TLS != nil {
+			err = server.ServeTLS(lis, "", "")
+		} else {
+			err = server.Serve(lis)
+		}
+
 		if err != nil {
 			return
 		}
@@ -0,0 +1,150 @@
+package exporter
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+)
+
+const (
+	statusOK      = "ok"
+	statusFailing = "failing"
+
+	// maxRegistryFailures is how many tag listings in a row may fail before the exporter is no longer ready
+	maxRegistryFailures = 10
+
+	// maxEventDuration is how long a container image may be in progress before the pipeline counts as stuck. Checks
+	// time out after a few seconds, so this is only reached if a worker hangs.
+	maxEventDuration = 5 * time.Minute
+)
+
+type healthResponse struct {
+	Status     string                       `json:"status"`
+	Components map[string]componentResponse `json:"components"`
+}
+
+type componentResponse struct {
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+type healthCheck func(health evaluation.Health, now time.Time) componentResponse
+
+// NewReadyHandler serves /ready. It fails until the container sources are synced and every container image they
+// listed at the start was evaluated, and while every registry request fails.
+func NewReadyHandler(evaluator *evaluation.Evaluator) http.Handler {
+	return healthHandler(evaluator, map[string]healthCheck{
+		"sources":    checkSources,
+		"evaluation": checkEvaluation,
+		"registry":   checkRegistry,
+	})
+}
+
+// NewHealthHandler serves /healthz. It fails if the evaluator stopped without finishing its sources, or an event is
+// stuck in the pipeline.
+func NewHealthHandler(evaluator *evaluation.Evaluator) http.Handler {
+	return healthHandler(evaluator, map[string]healthCheck{
+		"evaluator": checkEvaluator,
+		"pipeline":  checkPipeline,
+	})
+}
+
+func healthHandler(evaluator *evaluation.Evaluator, checks map[string]healthCheck) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		health := evaluator.Health()
+		now := time.Now()
+
+		response := healthResponse{
+			Status:     statusOK,
+			Components: map[string]componentResponse{},
+		}
+
+		for name, check := range checks {
+			component := check(health, now)
+			if component.Status != statusOK {
+				response.Status = statusFailing
+			}
+
+			response.Components[name] = component
+		}
+
+		status := http.StatusOK
+		if response.Status != statusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		writeJSON(writer, status, response)
+	})
+}
+
+func checkSources(health evaluation.Health, _ time.Time) componentResponse {
+	if !health.Synced {
+		return componentResponse{Status: statusFailing, Detail: "container sources are not synced yet"}
+	}
+
+	return componentResponse{Status: statusOK, Detail: "container sources are synced"}
+}
+
+func checkEvaluation(health evaluation.Health, _ time.Time) componentResponse {
+	detail := fmt.Sprintf("%d container images evaluated", health.Evaluated)
+
+	if !health.Initialized && !health.Finished {
+		return componentResponse{Status: statusFailing, Detail: detail + ", initial listing still in progress"}
+	}
+
+	return componentResponse{Status: statusOK, Detail: detail}
+}
+
+func checkRegistry(health evaluation.Health, now time.Time) componentResponse {
+	lastSuccess := "never"
+	if !health.LastRegistrySuccess.IsZero() {
+		lastSuccess = now.Sub(health.LastRegistrySuccess).Round(time.Second).String() + " ago"
+	}
+
+	detail := fmt.Sprintf("%d tag listings failed in a row, last success %s", health.RegistryFailures, lastSuccess)
+
+	if health.RegistryFailures >= maxRegistryFailures {
+		return componentResponse{Status: statusFailing, Detail: detail}
+	}
+
+	return componentResponse{Status: statusOK, Detail: detail}
+}
+
+func checkEvaluator(health evaluation.Health, _ time.Time) componentResponse {
+	switch {
+	case health.Stopped:
+		return componentResponse{Status: statusFailing, Detail: "container sources stopped watching"}
+	case health.Running:
+		return componentResponse{Status: statusOK, Detail: "evaluator is running"}
+	case health.Finished:
+		return componentResponse{Status: statusOK, Detail: "container sources finished, all images evaluated"}
+	}
+
+	return componentResponse{Status: statusFailing, Detail: "evaluator is not running"}
+}
+
+func checkPipeline(health evaluation.Health, now time.Time) componentResponse {
+	lastEvent := "no events yet"
+	if !health.LastEvent.IsZero() {
+		lastEvent = "last event " + now.Sub(health.LastEvent).Round(time.Second).String() + " ago"
+	}
+
+	if health.Stopped {
+		return componentResponse{Status: statusFailing, Detail: "container sources stopped watching, " + lastEvent}
+	}
+
+	if health.OldestInFlight.IsZero() {
+		return componentResponse{Status: statusOK, Detail: "idle, " + lastEvent}
+	}
+
+	inFlight := now.Sub(health.OldestInFlight)
+	detail := fmt.Sprintf("oldest event in progress for %s, %s", inFlight.Round(time.Second), lastEvent)
+
+	if inFlight > maxEventDuration {
+		return componentResponse{Status: statusFailing, Detail: detail}
+	}
+
+	return componentResponse{Status: statusOK, Detail: detail}
+}
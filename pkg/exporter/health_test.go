@@ -0,0 +1,134 @@
+package exporter_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/exporter"
+)
+
+type healthResponse struct {
+	Status     string `json:"status"`
+	Components map[string]struct {
+		Status string `json:"status"`
+		Detail string `json:"detail"`
+	} `json:"components"`
+}
+
+func getHealth(t *testing.T, handler http.Handler, expectedStatus int) healthResponse {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, expectedStatus, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var health healthResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&health))
+
+	return health
+}
+
+func TestHealth_NotRunning(t *testing.T) {
+	evaluator := evaluationtest.NewEvaluator(t, make(evaluationtest.Client), evaluation.Config{})
+
+	ready := getHealth(t, exporter.NewReadyHandler(evaluator), http.StatusServiceUnavailable)
+	require.Equal(t, "failing", ready.Status)
+	require.Equal(t, "failing", ready.Components["sources"].Status)
+	require.Equal(t, "failing", ready.Components["evaluation"].Status)
+	require.Equal(t, "ok", ready.Components["registry"].Status)
+
+	health := getHealth(t, exporter.NewHealthHandler(evaluator), http.StatusServiceUnavailable)
+	require.Equal(t, "failing", health.Components["evaluator"].Status)
+	require.Equal(t, "ok", health.Components["pipeline"].Status)
+}
+
+func TestHealth_Evaluated(t *testing.T) {
+	evaluator, _, _ := newEvaluator(t)
+
+	ready := getHealth(t, exporter.NewReadyHandler(evaluator), http.StatusOK)
+	require.Equal(t, "ok", ready.Status)
+	require.Equal(t, "3 container images evaluated", ready.Components["evaluation"].Detail)
+
+	health := getHealth(t, exporter.NewHealthHandler(evaluator), http.StatusOK)
+	require.Equal(t, "ok", health.Status)
+	require.Equal(t, "ok", health.Components["pipeline"].Status)
+}
+
+func TestHealth_RegistryFailing(t *testing.T) {
+	host := evaluationtest.UnreachableHost(t)
+
+	client := make(evaluationtest.Client, 10)
+
+	for i := range 10 {
+		client <- clients.ContainerImage{
+			Action: clients.ContainerImageAdded,
+			Name:   fmt.Sprintf("default/web-%d/app", i),
+			Image:  host + "/app:1.0.0",
+		}
+	}
+
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1})
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	ready := getHealth(t, exporter.NewReadyHandler(evaluator), http.StatusServiceUnavailable)
+	require.Equal(t, "ok", ready.Components["sources"].Status)
+	require.Equal(t, "failing", ready.Components["registry"].Status)
+	require.Equal(t, "10 tag listings failed in a row, last success never", ready.Components["registry"].Detail)
+
+	getHealth(t, exporter.NewHealthHandler(evaluator), http.StatusOK)
+}
+
+func TestHealth_InitialListing(t *testing.T) {
+	host := evaluationtest.UnreachableHost(t)
+
+	client := make(evaluationtest.Client)
+	t.Cleanup(func() { close(client) })
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1})
+
+	go func() {
+		_ = evaluator.Run(context.Background())
+	}()
+
+	client <- clients.ContainerImage{
+		Action: clients.ContainerImageAdded,
+		Name:   "default/web/app",
+		Image:  host + "/app:1.0.0",
+	}
+
+	ready := getHealth(t, exporter.NewReadyHandler(evaluator), http.StatusServiceUnavailable)
+	require.Equal(t, "failing", ready.Components["evaluation"].Status)
+
+	client <- clients.ContainerImage{Action: clients.ContainerImagesListed}
+
+	require.Eventually(t, func() bool { return evaluator.Health().Initialized }, 5*time.Second, 10*time.Millisecond)
+
+	ready = getHealth(t, exporter.NewReadyHandler(evaluator), http.StatusOK)
+	require.Equal(t, "0 container images evaluated", ready.Components["evaluation"].Detail)
+}
+
+func TestHealth_SourcesStopped(t *testing.T) {
+	client := make(evaluationtest.Client)
+	close(client)
+
+	// A watching source closes its channel after its event stream failed
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1, Watch: true})
+	require.ErrorIs(t, evaluator.Run(context.Background()), evaluation.ErrSourcesStopped)
+
+	health := getHealth(t, exporter.NewHealthHandler(evaluator), http.StatusServiceUnavailable)
+	require.Equal(t, "failing", health.Components["evaluator"].Status)
+	require.Equal(t, "container sources stopped watching", health.Components["evaluator"].Detail)
+	require.Equal(t, "failing", health.Components["pipeline"].Status)
+}
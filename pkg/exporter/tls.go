@@ -0,0 +1,146 @@
+package exporter
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// NewTLSConfig serves the key pair from certFile and keyFile. The files are read again when they change, so rotated
+// certificates are picked up without a restart. If clientCAFile is set, client certificates are verified against it,
+// but still optional, so clients can authenticate with a bearer token instead.
+func NewTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	loader := &certificateLoader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+	}
+
+	_, err := loader.load()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			keyPair, err := loader.load()
+
+			return keyPair.certificate, err
+		},
+	}
+
+	if clientCAFile == "" {
+		return config, nil
+	}
+
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		keyPair, err := loader.load()
+		if err != nil {
+			return nil, err
+		}
+
+		return &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{*keyPair.certificate},
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+			ClientCAs:    keyPair.clientCAs,
+		}, nil
+	}
+
+	return config, nil
+}
+
+type certificateLoader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mutex   sync.Mutex
+	current loadedCertificate
+}
+
+type loadedCertificate struct {
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+
+	// modTime is the newest modification time of the files
+	modTime time.Time
+}
+
+// load returns the current key pair and client CAs, reading the files again if one of them changed. A file that
+// can't be read keeps the previous certificate, e.g. while a rotation is written.
+func (l *certificateLoader) load() (loadedCertificate, error) {
+	modTime, err := l.modTime()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err == nil && l.current.certificate != nil && modTime.Equal(l.current.modTime) {
+		return l.current, nil
+	}
+
+	loaded, loadErr := l.read()
+	if loadErr == nil && err == nil {
+		loaded.modTime = modTime
+		l.current = loaded
+
+		return l.current, nil
+	}
+
+	if l.current.certificate != nil {
+		return l.current, nil
+	}
+
+	return loadedCertificate{}, errors.Join(err, loadErr)
+}
+
+func (l *certificateLoader) modTime() (time.Time, error) {
+	var newest time.Time
+
+	for _, file := range []string{l.certFile, l.keyFile, l.clientCAFile} {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+
+	return newest, nil
+}
+
+func (l *certificateLoader) read() (loadedCertificate, error) {
+	certificate, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return loadedCertificate{}, err
+	}
+
+	loaded := loadedCertificate{certificate: &certificate}
+
+	if l.clientCAFile == "" {
+		return loaded, nil
+	}
+
+	content, err := os.ReadFile(l.clientCAFile)
+	if err != nil {
+		return loadedCertificate{}, err
+	}
+
+	loaded.clientCAs = x509.NewCertPool()
+	if !loaded.clientCAs.AppendCertsFromPEM(content) {
+		return loadedCertificate{}, fmt.Errorf("%s: no PEM encoded certificates found", l.clientCAFile)
+	}
+
+	return loaded, nil
+}
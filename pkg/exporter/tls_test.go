@@ -0,0 +1,160 @@
+package exporter_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/exporter"
+)
+
+type certificateAuthority struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         []byte
+}
+
+func newCertificateAuthority(t *testing.T) certificateAuthority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return certificateAuthority{
+		certificate: certificate,
+		key:         key,
+		pem:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue returns a PEM encoded certificate and key for the subject, valid for 127.0.0.1.
+func (ca certificateAuthority) issue(t *testing.T, serial int64, subject pkix.Name, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, content []byte, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	ca := newCertificateAuthority(t)
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+
+	modTime := time.Now().Add(-time.Minute)
+	certPEM, keyPEM := ca.issue(t, 2, pkix.Name{CommonName: "server"}, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, modTime)
+	writeFile(t, keyFile, keyPEM, modTime)
+	writeFile(t, caFile, ca.pem, modTime)
+
+	tlsConfig, err := exporter.NewTLSConfig(certFile, keyFile, caFile)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if len(request.TLS.VerifiedChains) > 0 {
+			_, _ = writer.Write([]byte(request.TLS.VerifiedChains[0][0].Subject.CommonName))
+		}
+	}))
+	server.TLS = tlsConfig
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+
+	serverSerial := func(certificates ...tls.Certificate) (int64, string) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certificates,
+		}}}
+
+		response, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer response.Body.Close()
+
+		body := make([]byte, 64)
+		n, _ := response.Body.Read(body)
+
+		return response.TLS.PeerCertificates[0].SerialNumber.Int64(), string(body[:n])
+	}
+
+	serial, user := serverSerial()
+	require.Equal(t, int64(2), serial)
+	require.Empty(t, user)
+
+	clientCertPEM, clientKeyPEM := ca.issue(t, 3, pkix.Name{CommonName: "prometheus"}, x509.ExtKeyUsageClientAuth)
+	clientCertificate, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	require.NoError(t, err)
+
+	_, user = serverSerial(clientCertificate)
+	require.Equal(t, "prometheus", user)
+
+	// A partially written rotation keeps the previous certificate
+	writeFile(t, certFile, []byte("garbage"), modTime.Add(time.Second))
+
+	serial, _ = serverSerial()
+	require.Equal(t, int64(2), serial)
+
+	certPEM, keyPEM = ca.issue(t, 4, pkix.Name{CommonName: "server"}, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, modTime.Add(2*time.Second))
+	writeFile(t, keyFile, keyPEM, modTime.Add(2*time.Second))
+
+	serial, _ = serverSerial()
+	require.Equal(t, int64(4), serial)
+}
+
+func TestNewTLSConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := exporter.NewTLSConfig(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), "")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,342 @@
+package imagereport
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+)
+
+var GroupVersionResource = schema.GroupVersionResource{
+	Group:    "outdated-images.patrick246.de",
+	Version:  "v1alpha1",
+	Resource: "outdatedimagereports",
+}
+
+const (
+	Kind         = "OutdatedImageReport"
+	FieldManager = "outdated-image-exporter"
+
+	maxRetries = 10
+)
+
+type Config struct {
+	// WriteDelay collects the results of a workload's pods before its report is written
+	WriteDelay time.Duration
+
+	// DeleteDelay keeps the report of a workload without pods, so rollouts don't recreate it
+	DeleteDelay time.Duration
+}
+
+// Writer maintains an OutdatedImageReport per workload from the results of the evaluator. Reports are applied
+// server-side and owned by their workload, so they are garbage collected with it.
+type Writer struct {
+	Config Config
+
+	client    dynamic.Interface
+	workqueue workqueue.RateLimitingInterface
+	logger    *slog.Logger
+
+	mutex     sync.Mutex
+	workloads map[string]*workloadResults
+}
+
+type workloadResults struct {
+	workload k8s.Workload
+
+	// containers maps container names to the results of every pod running the container
+	containers map[string]map[string]evaluation.Result
+
+	emptySince time.Time
+}
+
+func NewWriter(config Config, client dynamic.Interface, logger *slog.Logger) *Writer {
+	queue := workqueue.NewRateLimitingQueueWithConfig(
+		workqueue.DefaultControllerRateLimiter(),
+		workqueue.RateLimitingQueueConfig{Name: "imagereports"},
+	)
+
+	return &Writer{
+		Config:    config,
+		client:    client,
+		workqueue: queue,
+		logger:    logger,
+		workloads: map[string]*workloadResults{},
+	}
+}
+
+func (w *Writer) ResultUpdated(result evaluation.Result) {
+	workload, container, ok := workloadOf(result)
+	if !ok {
+		return
+	}
+
+	key := workloadKey(workload)
+
+	w.mutex.Lock()
+
+	entry, ok := w.workloads[key]
+	if !ok {
+		entry = &workloadResults{
+			workload:   workload,
+			containers: map[string]map[string]evaluation.Result{},
+		}
+		w.workloads[key] = entry
+	}
+
+	entry.workload = workload
+
+	if entry.containers[container] == nil {
+		entry.containers[container] = map[string]evaluation.Result{}
+	}
+
+	entry.containers[container][result.ContainerImage.Name] = result
+
+	w.mutex.Unlock()
+
+	w.workqueue.AddAfter(key, w.Config.WriteDelay)
+}
+
+func (w *Writer) ResultRemoved(result evaluation.Result) {
+	workload, container, ok := workloadOf(result)
+	if !ok {
+		return
+	}
+
+	key := workloadKey(workload)
+
+	w.mutex.Lock()
+
+	entry, ok := w.workloads[key]
+	if ok {
+		delete(entry.containers[container], result.ContainerImage.Name)
+
+		if len(entry.containers[container]) == 0 {
+			delete(entry.containers, container)
+		}
+
+		if len(entry.containers) == 0 {
+			entry.emptySince = time.Now()
+		}
+	}
+
+	w.mutex.Unlock()
+
+	if ok {
+		w.workqueue.AddAfter(key, w.Config.WriteDelay)
+	}
+}
+
+// Run writes reports until the context is cancelled.
+func (w *Writer) Run(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		w.workqueue.ShutDown()
+	}()
+
+	for key, quit := w.workqueue.Get(); !quit; key, quit = w.workqueue.Get() {
+		w.processWorkqueue(ctx, key.(string))
+	}
+}
+
+func (w *Writer) processWorkqueue(ctx context.Context, key string) {
+	defer w.workqueue.Done(key)
+
+	err := w.write(ctx, key)
+	if err == nil {
+		w.workqueue.Forget(key)
+
+		return
+	}
+
+	w.logger.Warn("error writing OutdatedImageReport", "workload", key, "error", err)
+
+	if w.workqueue.NumRequeues(key) < maxRetries {
+		w.workqueue.AddRateLimited(key)
+	} else {
+		w.workqueue.Forget(key)
+	}
+}
+
+func (w *Writer) write(ctx context.Context, key string) error {
+	w.mutex.Lock()
+
+	entry, ok := w.workloads[key]
+	if !ok {
+		w.mutex.Unlock()
+
+		return nil
+	}
+
+	workload := entry.workload
+
+	if len(entry.containers) == 0 {
+		if wait := w.Config.DeleteDelay - time.Since(entry.emptySince); wait > 0 {
+			w.mutex.Unlock()
+			w.workqueue.AddAfter(key, wait)
+
+			return nil
+		}
+
+		delete(w.workloads, key)
+		w.mutex.Unlock()
+
+		err := w.client.Resource(GroupVersionResource).Namespace(workload.Namespace).Delete(ctx, ReportName(workload), metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	report := buildReport(entry)
+	w.mutex.Unlock()
+
+	_, err := w.client.Resource(GroupVersionResource).Namespace(workload.Namespace).Apply(ctx, report.GetName(), report, metav1.ApplyOptions{
+		FieldManager: FieldManager,
+		Force:        true,
+	})
+
+	return err
+}
+
+// ReportName is the name of the report of a workload, e.g. deployment-web.
+func ReportName(workload k8s.Workload) string {
+	return strings.ToLower(workload.Kind) + "-" + workload.Name
+}
+
+func workloadKey(workload k8s.Workload) string {
+	return workload.Namespace + "/" + ReportName(workload)
+}
+
+func workloadOf(result evaluation.Result) (k8s.Workload, string, bool) {
+	workload, ok := result.ContainerImage.Metadata[clients.MetadataWorkload].(k8s.Workload)
+	if !ok {
+		return k8s.Workload{}, "", false
+	}
+
+	container, ok := result.ContainerImage.Metadata[clients.MetadataContainer].(string)
+
+	return workload, container, ok
+}
+
+// buildReport creates the complete report of a workload. Pods of the same workload share a container entry, the
+// most recent check wins.
+func buildReport(entry *workloadResults) *unstructured.Unstructured {
+	names := make([]string, 0, len(entry.containers))
+	for name := range entry.containers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	containers := make([]interface{}, 0, len(names))
+	worst := evaluation.SeverityNone
+	outdated := int64(0)
+
+	var lastChecked time.Time
+
+	for _, name := range names {
+		var latest evaluation.Result
+		for _, result := range entry.containers[name] {
+			if latest.ContainerImage.Name == "" || result.CheckedAt.After(latest.CheckedAt) {
+				latest = result
+			}
+		}
+
+		severity := latest.Severity()
+		if severity > worst {
+			worst = severity
+		}
+
+		if severity != evaluation.SeverityNone {
+			outdated++
+		}
+
+		if latest.CheckedAt.After(lastChecked) {
+			lastChecked = latest.CheckedAt
+		}
+
+		containers = append(containers, containerStatus(name, latest))
+	}
+
+	workload := entry.workload
+
+	report := &unstructured.Unstructured{Object: map[string]interface{}{
+		"workload": map[string]interface{}{
+			"apiVersion": workload.APIVersion,
+			"kind":       workload.Kind,
+			"name":       workload.Name,
+		},
+		"summary": map[string]interface{}{
+			"severity":    worst.String(),
+			"outdated":    outdated,
+			"containers":  int64(len(containers)),
+			"lastChecked": lastChecked.UTC().Format(time.RFC3339),
+		},
+		"containers": containers,
+	}}
+
+	report.SetAPIVersion(GroupVersionResource.GroupVersion().String())
+	report.SetKind(Kind)
+	report.SetNamespace(workload.Namespace)
+	report.SetName(ReportName(workload))
+	report.SetLabels(map[string]string{
+		"app.kubernetes.io/managed-by": FieldManager,
+	})
+	report.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: workload.APIVersion,
+		Kind:       workload.Kind,
+		Name:       workload.Name,
+		UID:        workload.UID,
+	}})
+
+	return report
+}
+
+func containerStatus(name string, result evaluation.Result) map[string]interface{} {
+	status := map[string]interface{}{
+		"name":        name,
+		"image":       result.ContainerImage.Image,
+		"severity":    result.Severity().String(),
+		"lastChecked": result.CheckedAt.UTC().Format(time.RFC3339),
+	}
+
+	if result.Err != nil {
+		status["error"] = result.Err.Error()
+
+		return status
+	}
+
+	status["current"] = result.Current
+	status["major"] = result.Major
+	status["minor"] = result.Minor
+	status["patch"] = result.Patch
+
+	for field, value := range map[string]string{
+		"latest":      result.Latest,
+		"latestMajor": result.LatestMajor,
+		"latestMinor": result.LatestMinor,
+		"latestPatch": result.LatestPatch,
+		"policy":      result.Policy,
+	} {
+		if value != "" {
+			status[field] = value
+		}
+	}
+
+	return status
+}
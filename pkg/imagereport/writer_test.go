@@ -0,0 +1,127 @@
+package imagereport_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/imagereport"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+var deployment = k8s.Workload{
+	APIVersion: "apps/v1",
+	Kind:       "Deployment",
+	Namespace:  "prod",
+	Name:       "web",
+	UID:        types.UID("3f0c"),
+}
+
+func TestWriter(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	actions := make(chan k8stesting.Action, 10)
+
+	client.PrependReactor("*", "outdatedimagereports", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		actions <- action
+
+		return true, nil, nil
+	})
+
+	writer := imagereport.NewWriter(imagereport.Config{}, client, slog.Default())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go writer.Run(ctx)
+
+	checkedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	previous := evaluationtest.Result(deployment, "web-1", "nginx", "nginx:1.24.0", version.Evaluation{Current: "1.24.0"})
+	previous.CheckedAt = checkedAt.Add(-time.Hour)
+	writer.ResultUpdated(previous)
+
+	outdated := evaluationtest.Result(deployment, "web-2", "nginx", "nginx:1.24.0", version.Evaluation{
+		Current:     "1.24.0",
+		Minor:       1,
+		Latest:      "1.25.3",
+		LatestMinor: "1.25.3",
+	})
+	outdated.CheckedAt = checkedAt
+	writer.ResultUpdated(outdated)
+
+	failed := evaluationtest.Result(deployment, "web-2", "sidecar", "registry.internal/sidecar:1.0.0", version.Evaluation{})
+	failed.Err = errors.New("unauthorized")
+	failed.CheckedAt = checkedAt
+	writer.ResultUpdated(failed)
+
+	var applied *unstructured.Unstructured
+
+	for applied == nil || len(applied.Object["containers"].([]interface{})) < 2 {
+		action := <-actions
+		require.Equal(t, "patch", action.GetVerb())
+
+		patch := action.(k8stesting.PatchAction)
+		require.Equal(t, types.ApplyPatchType, patch.GetPatchType())
+		require.Equal(t, "deployment-web", patch.GetName())
+		require.Equal(t, "prod", patch.GetNamespace())
+
+		applied = &unstructured.Unstructured{}
+		require.NoError(t, yaml.Unmarshal(patch.GetPatch(), &applied.Object))
+	}
+
+	require.Equal(t, "OutdatedImageReport", applied.GetKind())
+	require.Equal(t, "Deployment", applied.GetOwnerReferences()[0].Kind)
+	require.Equal(t, types.UID("3f0c"), applied.GetOwnerReferences()[0].UID)
+	require.Equal(t, map[string]interface{}{
+		"severity":    "minor",
+		"outdated":    int64(1),
+		"containers":  int64(2),
+		"lastChecked": "2026-10-18T12:00:00Z",
+	}, applied.Object["summary"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name":        "nginx",
+			"image":       "nginx:1.24.0",
+			"severity":    "minor",
+			"current":     "1.24.0",
+			"major":       int64(0),
+			"minor":       int64(1),
+			"patch":       int64(0),
+			"latest":      "1.25.3",
+			"latestMinor": "1.25.3",
+			"lastChecked": "2026-10-18T12:00:00Z",
+		},
+		map[string]interface{}{
+			"name":        "sidecar",
+			"image":       "registry.internal/sidecar:1.0.0",
+			"severity":    "none",
+			"error":       "unauthorized",
+			"lastChecked": "2026-10-18T12:00:00Z",
+		},
+	}, applied.Object["containers"])
+
+	writer.ResultRemoved(previous)
+	writer.ResultRemoved(outdated)
+	writer.ResultRemoved(failed)
+
+	for {
+		action := <-actions
+		if action.GetVerb() == "delete" {
+			require.Equal(t, "deployment-web", action.(k8stesting.DeleteAction).GetName())
+
+			break
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/tools/cache"
+)
+
+var informerSyncedDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "informer", "synced"),
+	"Is 1 once the cache of the informer is synced",
+	[]string{"informer"},
+	nil,
+)
+
+// informerCollector reports the sync status of the informers added with AddInformer at collection time.
+type informerCollector struct {
+	mutex     sync.RWMutex
+	hasSynced map[string]cache.InformerSynced
+}
+
+var informers = &informerCollector{hasSynced: map[string]cache.InformerSynced{}}
+
+// AddInformer reports the sync status of an informer under the given name.
+func AddInformer(name string, hasSynced cache.InformerSynced) {
+	informers.mutex.Lock()
+	defer informers.mutex.Unlock()
+
+	informers.hasSynced[name] = hasSynced
+}
+
+func (i *informerCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- informerSyncedDesc
+}
+
+func (i *informerCollector) Collect(metrics chan<- prometheus.Metric) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	for name, hasSynced := range i.hasSynced {
+		value := 0.0
+		if hasSynced() {
+			value = 1
+		}
+
+		metrics <- prometheus.MustNewConstMetric(informerSyncedDesc, prometheus.GaugeValue, value, name)
+	}
+}
@@ -0,0 +1,59 @@
+// Package metrics holds the metrics the exporter reports about itself, as opposed to the container image metrics
+// of the evaluator.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const namespace = "outdated_image_exporter"
+
+var (
+	// RegistryRequestDuration observes every HTTP request to a registry or its token service
+	RegistryRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "registry_request_duration_seconds",
+		Help:      "Duration of HTTP requests to registries by host and status code",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"registry", "code"})
+
+	// EvaluationDuration observes listing and evaluating the tags of an image, shared evaluations are observed once
+	EvaluationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "evaluation_duration_seconds",
+		Help:      "Duration of image evaluations by check status reason",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"reason"})
+
+	// SourceEvents counts the container images reported by the sources
+	SourceEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "source_events_total",
+		Help:      "Number of container images added or removed by the container sources",
+	}, []string{"action"})
+)
+
+// Register adds the exporter's own metrics to the registerer and reports the metrics of every workqueue created
+// afterwards.
+func Register(registerer prometheus.Registerer) error {
+	collectors := []prometheus.Collector{
+		RegistryRequestDuration,
+		EvaluationDuration,
+		SourceEvents,
+		informers,
+	}
+
+	collectors = append(collectors, workqueueMetrics.collectors()...)
+
+	for _, collector := range collectors {
+		err := registerer.Register(collector)
+		if err != nil {
+			return err
+		}
+	}
+
+	workqueue.SetProvider(workqueueMetrics)
+
+	return nil
+}
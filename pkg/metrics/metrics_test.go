@@ -0,0 +1,85 @@
+package metrics_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/metrics"
+)
+
+func TestInstrumentRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := &http.Client{Transport: metrics.InstrumentRoundTripper(http.DefaultTransport)}
+
+	for range 2 {
+		response, err := client.Get(server.URL + "/v2/")
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+	}
+
+	failing := metrics.InstrumentRoundTripper(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))
+
+	request, err := http.NewRequest(http.MethodGet, "http://registry.invalid/v2/", nil)
+	require.NoError(t, err)
+
+	_, err = failing.RoundTrip(request)
+	require.Error(t, err)
+
+	require.Equal(t, 2, histogramCount(t, metrics.RegistryRequestDuration.WithLabelValues(host, "429")))
+	require.Equal(t, 1, histogramCount(t, metrics.RegistryRequestDuration.WithLabelValues("registry.invalid", "error")))
+}
+
+func TestAddInformer(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, metrics.Register(registry))
+
+	synced := false
+	metrics.AddInformer("pods", func() bool { return synced })
+
+	expected := `
+# HELP outdated_image_exporter_informer_synced Is 1 once the cache of the informer is synced
+# TYPE outdated_image_exporter_informer_synced gauge
+outdated_image_exporter_informer_synced{informer="pods"} %s
+`
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(fmt.Sprintf(expected, "0")), "outdated_image_exporter_informer_synced"))
+
+	synced = true
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(fmt.Sprintf(expected, "1")), "outdated_image_exporter_informer_synced"))
+}
+
+func histogramCount(t *testing.T, observer prometheus.Observer) int {
+	collector, ok := observer.(prometheus.Collector)
+	require.True(t, ok)
+
+	metric := make(chan prometheus.Metric, 1)
+	collector.Collect(metric)
+
+	var written dto.Metric
+	require.NoError(t, (<-metric).Write(&written))
+
+	return int(written.GetHistogram().GetSampleCount())
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
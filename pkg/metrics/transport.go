@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// InstrumentRoundTripper observes the duration of every request sent through next in RegistryRequestDuration.
+// Requests that fail without a response are reported with code "error".
+func InstrumentRoundTripper(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		start := time.Now()
+
+		response, err := next.RoundTrip(request)
+
+		code := "error"
+		if err == nil {
+			code = strconv.Itoa(response.StatusCode)
+		}
+
+		RegistryRequestDuration.WithLabelValues(request.URL.Host, code).Observe(time.Since(start).Seconds())
+
+		return response, err
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// workqueueProvider exports the metrics of named workqueues, using the metric names of the Kubernetes controllers.
+type workqueueProvider struct {
+	depth                   *prometheus.GaugeVec
+	adds                    *prometheus.CounterVec
+	latency                 *prometheus.HistogramVec
+	workDuration            *prometheus.HistogramVec
+	unfinishedWork          *prometheus.GaugeVec
+	longestRunningProcessor *prometheus.GaugeVec
+	retries                 *prometheus.CounterVec
+}
+
+var workqueueMetrics = &workqueueProvider{
+	depth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "workqueue",
+		Name:      "depth",
+		Help:      "Current depth of the workqueue",
+	}, []string{"name"}),
+	adds: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "workqueue",
+		Name:      "adds_total",
+		Help:      "Number of adds handled by the workqueue",
+	}, []string{"name"}),
+	latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "workqueue",
+		Name:      "queue_duration_seconds",
+		Help:      "How long an item stays in the workqueue before being requested",
+		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 12),
+	}, []string{"name"}),
+	workDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "workqueue",
+		Name:      "work_duration_seconds",
+		Help:      "How long processing an item from the workqueue takes",
+		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 12),
+	}, []string{"name"}),
+	unfinishedWork: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "workqueue",
+		Name:      "unfinished_work_seconds",
+		Help:      "Seconds of work in progress that hasn't been observed by work_duration yet",
+	}, []string{"name"}),
+	longestRunningProcessor: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "workqueue",
+		Name:      "longest_running_processor_seconds",
+		Help:      "Seconds the longest running processor of the workqueue has been running",
+	}, []string{"name"}),
+	retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "workqueue",
+		Name:      "retries_total",
+		Help:      "Number of retries handled by the workqueue",
+	}, []string{"name"}),
+}
+
+func (w *workqueueProvider) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		w.depth, w.adds, w.latency, w.workDuration, w.unfinishedWork, w.longestRunningProcessor, w.retries,
+	}
+}
+
+func (w *workqueueProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return w.depth.WithLabelValues(name)
+}
+
+func (w *workqueueProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return w.adds.WithLabelValues(name)
+}
+
+func (w *workqueueProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return w.latency.WithLabelValues(name)
+}
+
+func (w *workqueueProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return w.workDuration.WithLabelValues(name)
+}
+
+func (w *workqueueProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return w.unfinishedWork.WithLabelValues(name)
+}
+
+func (w *workqueueProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return w.longestRunningProcessor.WithLabelValues(name)
+}
+
+func (w *workqueueProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return w.retries.WithLabelValues(name)
+}
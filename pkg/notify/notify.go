@@ -0,0 +1,229 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/report"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/transition"
+)
+
+type Config struct {
+	// Interval in which changes are collected into one digest per receiver
+	Interval  time.Duration
+	Receivers []Receiver
+
+	// Initialized reports whether the images found at the start were evaluated. Results before only seed the state
+	// of their containers, so a restart doesn't notify every outdated image again. Nil notifies every change.
+	Initialized func() bool
+}
+
+// Notifier posts digests of images that became outdated, fell further behind or are up to date again to webhook
+// receivers. Replicas of a workload share their state, so a workload is reported once per change of its most
+// outdated replica.
+type Notifier struct {
+	config  Config
+	client  *http.Client
+	logger  *slog.Logger
+	tracker *transition.Tracker
+
+	mutex     sync.Mutex
+	receivers []*receiverState
+}
+
+type receiverState struct {
+	pending     []Item
+	nextSummary time.Time
+}
+
+func NewNotifier(config Config, client *http.Client, logger *slog.Logger) *Notifier {
+	now := time.Now()
+
+	receivers := make([]*receiverState, 0, len(config.Receivers))
+	for _, receiver := range config.Receivers {
+		receivers = append(receivers, &receiverState{
+			nextSummary: receiver.SummaryAt.next(now.In(location(receiver))),
+		})
+	}
+
+	return &Notifier{
+		config:    config,
+		client:    client,
+		logger:    logger,
+		tracker:   transition.NewTracker(),
+		receivers: receivers,
+	}
+}
+
+// Run sends the collected digests every interval until the context is done.
+func (n *Notifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			n.Flush(ctx, now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (n *Notifier) ResultUpdated(result evaluation.Result) {
+	if result.Err != nil {
+		return
+	}
+
+	previous, highest := n.tracker.Update(result)
+
+	if n.config.Initialized != nil && !n.config.Initialized() {
+		return
+	}
+
+	item := newItem(highest)
+
+	switch {
+	case item.Severity > previous:
+		item.Previous = previous
+	case item.Severity == evaluation.SeverityNone && previous != evaluation.SeverityNone:
+		item.Previous = previous
+		item.Resolved = true
+	default:
+		return
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	for i, receiver := range n.config.Receivers {
+		if receiver.DailySummary || !receiver.routes(item) {
+			continue
+		}
+
+		n.receivers[i].pending = append(n.receivers[i].pending, item)
+	}
+}
+
+func (n *Notifier) ResultRemoved(result evaluation.Result) {
+	n.tracker.Remove(result)
+}
+
+// Flush sends the digests that are due at the given time. Receivers in their quiet hours keep their changes until
+// the quiet hours are over.
+func (n *Notifier) Flush(ctx context.Context, now time.Time) {
+	digests := make([]Digest, len(n.config.Receivers))
+
+	n.mutex.Lock()
+
+	for i, receiver := range n.config.Receivers {
+		state := n.receivers[i]
+		local := now.In(location(receiver))
+
+		if receiver.QuietHours != nil && receiver.QuietHours.Contains(local) {
+			continue
+		}
+
+		if !receiver.DailySummary {
+			digests[i] = Digest{Receiver: receiver.Name, Items: state.pending}
+			state.pending = nil
+
+			continue
+		}
+
+		if local.Before(state.nextSummary) {
+			continue
+		}
+
+		state.nextSummary = receiver.SummaryAt.next(local)
+		digests[i] = Digest{Receiver: receiver.Name, Summary: true, Items: n.outdated(receiver)}
+	}
+
+	n.mutex.Unlock()
+
+	var wg sync.WaitGroup
+
+	for i, digest := range digests {
+		if len(digest.Items) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(receiver Receiver, digest Digest) {
+			defer wg.Done()
+
+			err := n.send(ctx, receiver, digest)
+			if err != nil {
+				n.logger.Error("error sending notification", "receiver", receiver.Name, "items", len(digest.Items), "error", err)
+			}
+		}(n.config.Receivers[i], digest)
+	}
+
+	wg.Wait()
+}
+
+// outdated lists the outdated images routed to a receiver.
+func (n *Notifier) outdated(receiver Receiver) []Item {
+	var items []Item
+
+	for _, result := range n.tracker.Current() {
+		item := newItem(result)
+		if !receiver.routes(item) {
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Namespace != items[j].Namespace {
+			return items[i].Namespace < items[j].Namespace
+		}
+
+		if items[i].Workload != items[j].Workload {
+			return items[i].Workload < items[j].Workload
+		}
+
+		return items[i].Container < items[j].Container
+	})
+
+	return items
+}
+
+func location(receiver Receiver) *time.Location {
+	if receiver.Location == nil {
+		return time.Local
+	}
+
+	return receiver.Location
+}
+
+func newItem(result evaluation.Result) Item {
+	item := Item{
+		Container: result.ContainerImage.Name,
+		Image:     result.ContainerImage.Image,
+		Latest:    result.Latest,
+		Severity:  result.Severity(),
+		Major:     result.Major,
+		Minor:     result.Minor,
+		Patch:     result.Patch,
+		Message:   report.Describe(result),
+	}
+
+	item.Namespace, _ = result.ContainerImage.Metadata[clients.MetadataNamespace].(string)
+
+	if workload, ok := result.ContainerImage.Metadata[clients.MetadataWorkload].(k8s.Workload); ok {
+		item.Workload = workload.Kind + "/" + workload.Name
+		item.Container, _ = result.ContainerImage.Metadata[clients.MetadataContainer].(string)
+	}
+
+	return item
+}
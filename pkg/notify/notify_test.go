@@ -0,0 +1,301 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/notify"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+type webhookServer struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	statuses []int
+	bodies   []string
+}
+
+// newWebhookServer records every request body and answers with the given statuses, then with 200.
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	server := &webhookServer{statuses: statuses}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, err := io.ReadAll(request.Body)
+		require.NoError(t, err)
+
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+
+		server.bodies = append(server.bodies, string(body))
+
+		status := http.StatusOK
+		if len(server.statuses) != 0 {
+			status, server.statuses = server.statuses[0], server.statuses[1:]
+		}
+
+		writer.WriteHeader(status)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *webhookServer) requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	bodies := s.bodies
+	s.bodies = nil
+
+	return bodies
+}
+
+func receiver(t *testing.T, url, format string) notify.Receiver {
+	template, err := notify.ParseTemplate(format, "")
+	require.NoError(t, err)
+
+	return notify.Receiver{
+		Name:        "test",
+		URL:         url,
+		Template:    template,
+		MinSeverity: evaluation.SeverityPatch,
+		Location:    time.UTC,
+		Retries:     3,
+		Backoff:     time.Millisecond,
+	}
+}
+
+var (
+	prodWeb = k8s.Workload{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "prod", Name: "web"}
+	devWeb  = k8s.Workload{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "dev", Name: "web"}
+)
+
+var (
+	upToDate = version.Evaluation{Latest: "1.24.0"}
+	patch    = version.Evaluation{Patch: 2, Latest: "1.24.2"}
+	minor    = version.Evaluation{Minor: 1, Latest: "1.25.3"}
+	major    = version.Evaluation{Major: 1, Latest: "2.0.0"}
+)
+
+func TestNotifier_Digest(t *testing.T) {
+	server := newWebhookServer(t)
+	notifier := notify.NewNotifier(notify.Config{
+		Receivers: []notify.Receiver{receiver(t, server.URL, "slack")},
+	}, server.Client(), slog.Default())
+
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", minor))
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-2", "nginx", "nginx:1.24.0", minor))
+	notifier.ResultUpdated(evaluationtest.Result(devWeb, "web-1", "nginx", "nginx:1.24.0", upToDate))
+	notifier.ResultUpdated(evaluationtest.Result(devWeb, "web-1", "nginx", "nginx:1.24.0", major))
+
+	notifier.Flush(context.Background(), time.Now())
+
+	require.Equal(t, []string{
+		`{"text": "2 image update(s)\n- prod/Deployment/web nginx: nginx:1.24.0 is 1 minor version(s) behind, newest allowed tag is 1.25.3\n- dev/Deployment/web nginx: nginx:1.24.0 is 1 major version(s) behind, newest allowed tag is 2.0.0\n"}`,
+	}, server.requests())
+
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", minor))
+	notifier.Flush(context.Background(), time.Now())
+	require.Empty(t, server.requests())
+
+	// Resolved once every replica is up to date
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", upToDate))
+	notifier.Flush(context.Background(), time.Now())
+	require.Empty(t, server.requests())
+
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-2", "nginx", "nginx:1.24.0", upToDate))
+	notifier.Flush(context.Background(), time.Now())
+	require.Equal(t, []string{
+		`{"text": "1 image update(s)\n- prod/Deployment/web nginx: nginx:1.24.0 is up-to-date\n"}`,
+	}, server.requests())
+}
+
+func TestNotifier_JSON(t *testing.T) {
+	server := newWebhookServer(t)
+	notifier := notify.NewNotifier(notify.Config{
+		Receivers: []notify.Receiver{receiver(t, server.URL, "json")},
+	}, server.Client(), slog.Default())
+
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", minor))
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", major))
+	notifier.Flush(context.Background(), time.Now())
+
+	requests := server.requests()
+	require.Len(t, requests, 1)
+
+	var digest map[string]any
+	require.NoError(t, json.Unmarshal([]byte(requests[0]), &digest))
+	require.Equal(t, "test", digest["receiver"])
+
+	items := digest["items"].([]any)
+	require.Len(t, items, 2)
+	require.Equal(t, "minor", items[0].(map[string]any)["severity"])
+	require.Equal(t, "major", items[1].(map[string]any)["severity"])
+	require.Equal(t, "minor", items[1].(map[string]any)["previous"])
+	require.Equal(t, "Deployment/web", items[1].(map[string]any)["workload"])
+}
+
+func TestNotifier_Routing(t *testing.T) {
+	server := newWebhookServer(t)
+
+	prod := receiver(t, server.URL, "json")
+	prod.Namespaces = []string{"prod"}
+	prod.MinSeverity = evaluation.SeverityMinor
+
+	notifier := notify.NewNotifier(notify.Config{
+		Receivers: []notify.Receiver{prod},
+	}, server.Client(), slog.Default())
+
+	notifier.ResultUpdated(evaluationtest.Result(devWeb, "web-1", "nginx", "nginx:1.24.0", major))
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", patch))
+	notifier.Flush(context.Background(), time.Now())
+	require.Empty(t, server.requests())
+
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", minor))
+	notifier.Flush(context.Background(), time.Now())
+	require.Len(t, server.requests(), 1)
+}
+
+func TestNotifier_QuietHours(t *testing.T) {
+	server := newWebhookServer(t)
+
+	quiet := receiver(t, server.URL, "slack")
+	quiet.QuietHours = &notify.QuietHours{
+		Start: notify.TimeOfDay(22 * time.Hour),
+		End:   notify.TimeOfDay(7 * time.Hour),
+	}
+
+	notifier := notify.NewNotifier(notify.Config{
+		Receivers: []notify.Receiver{quiet},
+	}, server.Client(), slog.Default())
+
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", minor))
+
+	notifier.Flush(context.Background(), time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC))
+	require.Empty(t, server.requests())
+
+	notifier.Flush(context.Background(), time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC))
+	require.Len(t, server.requests(), 1)
+}
+
+func TestNotifier_DailySummary(t *testing.T) {
+	server := newWebhookServer(t)
+
+	summary := receiver(t, server.URL, "slack")
+	summary.DailySummary = true
+	summary.SummaryAt = notify.TimeOfDay(9 * time.Hour)
+
+	notifier := notify.NewNotifier(notify.Config{
+		Receivers: []notify.Receiver{summary},
+	}, server.Client(), slog.Default())
+
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", minor))
+	notifier.ResultUpdated(evaluationtest.Result(devWeb, "web-1", "nginx", "nginx:1.24.0", upToDate))
+
+	notifier.Flush(context.Background(), time.Now())
+	require.Empty(t, server.requests())
+
+	tomorrow := time.Now().Add(24 * time.Hour)
+
+	notifier.Flush(context.Background(), tomorrow)
+	require.Equal(t, []string{
+		`{"text": "Daily summary: 1 outdated image(s)\n- prod/Deployment/web nginx: nginx:1.24.0 is 1 minor version(s) behind, newest allowed tag is 1.25.3\n"}`,
+	}, server.requests())
+
+	notifier.Flush(context.Background(), tomorrow.Add(time.Hour))
+	require.Empty(t, server.requests())
+}
+
+func TestNotifier_Retry(t *testing.T) {
+	server := newWebhookServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	notifier := notify.NewNotifier(notify.Config{
+		Receivers: []notify.Receiver{receiver(t, server.URL, "slack")},
+	}, server.Client(), slog.Default())
+
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", minor))
+	notifier.Flush(context.Background(), time.Now())
+	require.Len(t, server.requests(), 3)
+
+	server.statuses = []int{http.StatusBadRequest}
+
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", major))
+	notifier.Flush(context.Background(), time.Now())
+	require.Len(t, server.requests(), 1)
+}
+
+func TestQuietHours_Contains(t *testing.T) {
+	start, err := notify.ParseTimeOfDay("22:00")
+	require.NoError(t, err)
+
+	end, err := notify.ParseTimeOfDay("07:30")
+	require.NoError(t, err)
+
+	overnight := notify.QuietHours{Start: start, End: end}
+	daytime := notify.QuietHours{Start: end, End: start}
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	require.True(t, overnight.Contains(at(23, 0)))
+	require.True(t, overnight.Contains(at(7, 29)))
+	require.False(t, overnight.Contains(at(7, 30)))
+	require.False(t, overnight.Contains(at(12, 0)))
+	require.True(t, daytime.Contains(at(12, 0)))
+	require.False(t, daytime.Contains(at(22, 0)))
+
+	_, err = notify.ParseTimeOfDay("25:00")
+	require.Error(t, err)
+}
+
+func TestParseTemplate(t *testing.T) {
+	_, err := notify.ParseTemplate("email", "")
+	require.ErrorContains(t, err, `unknown format "email"`)
+
+	_, err = notify.ParseTemplate("", "{{ .Missing")
+	require.Error(t, err)
+
+	template, err := notify.ParseTemplate("", `{"count": {{ len .Items }}}`)
+	require.NoError(t, err)
+	require.NotNil(t, template)
+}
+
+func TestNotifier_Seeding(t *testing.T) {
+	server := newWebhookServer(t)
+
+	var initialized atomic.Bool
+
+	notifier := notify.NewNotifier(notify.Config{
+		Receivers:   []notify.Receiver{receiver(t, server.URL, "slack")},
+		Initialized: initialized.Load,
+	}, server.Client(), slog.Default())
+
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", minor))
+	notifier.ResultUpdated(evaluationtest.Result(devWeb, "web-1", "nginx", "nginx:1.24.0", upToDate))
+	notifier.Flush(context.Background(), time.Now())
+	require.Empty(t, server.requests())
+
+	initialized.Store(true)
+
+	notifier.ResultUpdated(evaluationtest.Result(prodWeb, "web-1", "nginx", "nginx:1.24.0", minor))
+	notifier.ResultUpdated(evaluationtest.Result(devWeb, "web-1", "nginx", "nginx:1.24.0", patch))
+	notifier.Flush(context.Background(), time.Now())
+	require.Equal(t, []string{
+		`{"text": "1 image update(s)\n- dev/Deployment/web nginx: nginx:1.24.0 is 2 patch version(s) behind, newest allowed tag is 1.24.2\n"}`,
+	}, server.requests())
+}
@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+)
+
+// Receiver is a webhook URL notifications are posted to.
+type Receiver struct {
+	Name    string
+	URL     string
+	Headers map[string]string
+
+	// Template renders the request body from a Digest
+	Template *template.Template
+
+	// Namespaces restricts the receiver to images of these namespaces, empty receives every image
+	Namespaces []string
+
+	// MinSeverity is the smallest version difference the receiver is notified about
+	MinSeverity evaluation.Severity
+
+	// Location is the time zone of QuietHours and SummaryAt
+	Location *time.Location
+
+	// QuietHours hold back digests until they are over, nil disables them
+	QuietHours *QuietHours
+
+	// DailySummary sends every outdated image once a day at SummaryAt instead of a digest of changes
+	DailySummary bool
+	SummaryAt    TimeOfDay
+
+	// Retries of a failed delivery, the delay starts at Backoff and doubles after every attempt
+	Retries int
+	Backoff time.Duration
+}
+
+// routes reports whether an item is sent to the receiver.
+func (r Receiver) routes(item Item) bool {
+	if len(r.Namespaces) != 0 && !slices.Contains(r.Namespaces, item.Namespace) {
+		return false
+	}
+
+	severity := item.Severity
+	if item.Resolved {
+		severity = item.Previous
+	}
+
+	return severity >= r.MinSeverity && severity != evaluation.SeverityNone
+}
+
+// TimeOfDay is the time since midnight.
+type TimeOfDay time.Duration
+
+// ParseTimeOfDay parses a time like 22:00.
+func ParseTimeOfDay(timeOfDay string) (TimeOfDay, error) {
+	parsed, err := time.Parse("15:04", timeOfDay)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected hh:mm", timeOfDay)
+	}
+
+	return TimeOfDay(time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute), nil
+}
+
+func timeOfDay(t time.Time) TimeOfDay {
+	return TimeOfDay(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second)
+}
+
+// next returns the first occurrence of the time of day after t.
+func (d TimeOfDay) next(t time.Time) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	next := midnight.Add(time.Duration(d))
+	if !next.After(t) {
+		next = midnight.AddDate(0, 0, 1).Add(time.Duration(d))
+	}
+
+	return next
+}
+
+// QuietHours is a daily time range, it may span midnight like 22:00 to 07:00.
+type QuietHours struct {
+	Start TimeOfDay
+	End   TimeOfDay
+}
+
+func (q QuietHours) Contains(t time.Time) bool {
+	now := timeOfDay(t)
+
+	if q.Start <= q.End {
+		return now >= q.Start && now < q.End
+	}
+
+	return now >= q.Start || now < q.End
+}
+
+// Formats are the request bodies for common webhook receivers.
+var Formats = map[string]string{
+	"json":  `{{ json . }}`,
+	"slack": `{"text": {{ json .Text }}}`,
+	"teams": `{"@type": "MessageCard", "@context": "https://schema.org/extensions", "summary": "Outdated images", "text": {{ json .Text }}}`,
+}
+
+// ParseTemplate parses a request body template. The template gets a Digest and may use the json function to encode
+// values. An empty template uses the given format.
+func ParseTemplate(format, text string) (*template.Template, error) {
+	if text == "" {
+		var ok bool
+
+		text, ok = Formats[format]
+		if !ok {
+			return nil, fmt.Errorf("unknown format %q, expected one of [json, slack, teams]", format)
+		}
+	}
+
+	return template.New("body").Funcs(template.FuncMap{
+		"json": func(value any) (string, error) {
+			encoded, err := json.Marshal(value)
+
+			return string(encoded), err
+		},
+	}).Parse(text)
+}
+
+// Digest is the data of a notification.
+type Digest struct {
+	Receiver string `json:"receiver"`
+
+	// Summary is set for daily summaries, which list every outdated image instead of changes
+	Summary bool   `json:"summary"`
+	Items   []Item `json:"items"`
+}
+
+// Item is an image that became outdated, fell further behind or is up to date again.
+type Item struct {
+	Namespace string `json:"namespace,omitempty"`
+	Workload  string `json:"workload,omitempty"`
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	Latest    string `json:"latest"`
+
+	Severity evaluation.Severity `json:"-"`
+	Previous evaluation.Severity `json:"-"`
+	Resolved bool                `json:"resolved"`
+
+	Major int64 `json:"major"`
+	Minor int64 `json:"minor"`
+	Patch int64 `json:"patch"`
+
+	// Message describes the item, e.g. "nginx:1.24.0 is 1 minor version(s) behind"
+	Message string `json:"message"`
+}
+
+func (i Item) MarshalJSON() ([]byte, error) {
+	type item Item
+
+	return json.Marshal(struct {
+		item
+		Severity string `json:"severity"`
+		Previous string `json:"previous"`
+	}{item(i), i.Severity.String(), i.Previous.String()})
+}
+
+// Text is a plain text version of the digest, one line per item.
+func (d Digest) Text() string {
+	var builder strings.Builder
+
+	if d.Summary {
+		_, _ = fmt.Fprintf(&builder, "Daily summary: %d outdated image(s)\n", len(d.Items))
+	} else {
+		_, _ = fmt.Fprintf(&builder, "%d image update(s)\n", len(d.Items))
+	}
+
+	for _, item := range d.Items {
+		location := item.Container
+		if item.Workload != "" {
+			location = item.Namespace + "/" + item.Workload + " " + item.Container
+		}
+
+		if item.Resolved {
+			_, _ = fmt.Fprintf(&builder, "- %s: %s\n", location, item.Message)
+
+			continue
+		}
+
+		_, _ = fmt.Fprintf(&builder, "- %s: %s, newest allowed tag is %s\n", location, item.Message, item.Latest)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const maxBackoff = 5 * time.Minute
+
+var errPermanent = errors.New("permanent failure")
+
+// send posts a digest to the receiver, retrying server errors, rate limits and connection problems with
+// exponential backoff.
+func (n *Notifier) send(ctx context.Context, receiver Receiver, digest Digest) error {
+	var body bytes.Buffer
+
+	err := receiver.Template.Execute(&body, digest)
+	if err != nil {
+		return fmt.Errorf("rendering template: %w", err)
+	}
+
+	backoff := receiver.Backoff
+
+	for attempt := 0; ; attempt++ {
+		err = n.post(ctx, receiver, body.Bytes())
+		if err == nil || errors.Is(err, errPermanent) || attempt >= receiver.Retries {
+			return err
+		}
+
+		n.logger.Warn("error sending notification, retrying", "receiver", receiver.Name, "attempt", attempt+1, "backoff", backoff, "error", err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, receiver Receiver, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, receiver.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	for name, value := range receiver.Headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 1<<16))
+
+	switch {
+	case response.StatusCode < 300:
+		return nil
+	case response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500:
+		return fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	return fmt.Errorf("%w: unexpected status %s", errPermanent, response.Status)
+}
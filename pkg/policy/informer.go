@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/metrics"
+)
+
+var (
+	ErrNotInstalled      = errors.New("ImageUpdatePolicy CRD is not installed")
+	ErrInformerCacheSync = errors.New("failed to synchronize policy informer cache")
+)
+
+// Watch keeps the store in sync with the ImageUpdatePolicies of the cluster. It returns once the initial policies
+// are loaded, so images checked afterwards already see them. ErrNotInstalled is returned if the CRD is missing.
+func Watch(ctx context.Context, config *rest.Config, store *Store, resyncInterval time.Duration, logger *slog.Logger) error {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	_, err = discoveryClient.ServerResourcesForGroupVersion(GroupVersionResource.GroupVersion().String())
+	if err != nil {
+		return errors.Join(ErrNotInstalled, err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, resyncInterval)
+	informer := factory.ForResource(GroupVersionResource).Informer()
+
+	update := func(obj interface{}) {
+		imageUpdatePolicy, err := fromUnstructured(obj)
+		if err != nil {
+			logger.Warn("error decoding ImageUpdatePolicy", "error", err)
+
+			return
+		}
+
+		policy, err := Compile(imageUpdatePolicy)
+		if err != nil {
+			logger.Warn("ignoring invalid ImageUpdatePolicy", "name", imageUpdatePolicy.Name, "error", err)
+			store.Delete(imageUpdatePolicy.Name)
+
+			return
+		}
+
+		logger.Info("ImageUpdatePolicy updated", "name", policy.Name)
+		store.Set(policy)
+	}
+
+	_, err = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: update,
+		UpdateFunc: func(_, obj interface{}) {
+			update(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+
+			object, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return
+			}
+
+			logger.Info("ImageUpdatePolicy deleted", "name", object.GetName())
+			store.Delete(object.GetName())
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.AddInformer("imageupdatepolicies", informer.HasSynced)
+
+	go informer.Run(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return ErrInformerCacheSync
+	}
+
+	return nil
+}
+
+func fromUnstructured(obj interface{}) (*ImageUpdatePolicy, error) {
+	object, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, errors.New("unexpected object type")
+	}
+
+	var imageUpdatePolicy ImageUpdatePolicy
+
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &imageUpdatePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &imageUpdatePolicy, nil
+}
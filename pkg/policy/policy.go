@@ -0,0 +1,251 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	goversion "github.com/hashicorp/go-version"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+// Policy is a parsed ImageUpdatePolicy, ready to be matched against images.
+type Policy struct {
+	Name   string
+	Ignore bool
+	Alert  *Thresholds
+
+	repositories  []repositoryPattern
+	namespaces    []string
+	labelSelector labels.Selector
+
+	pinMode     *version.PinMode
+	constraint  goversion.Constraints
+	scheme      *version.Scheme
+	includeTags *regexp.Regexp
+	excludeTags *regexp.Regexp
+	variants    *bool
+}
+
+type repositoryPattern struct {
+	pattern *regexp.Regexp
+
+	// literals is the number of characters outside of wildcards, more literals make a pattern more specific
+	literals int
+}
+
+// Compile validates an ImageUpdatePolicy and prepares it for matching.
+func Compile(imageUpdatePolicy *ImageUpdatePolicy) (*Policy, error) {
+	spec := imageUpdatePolicy.Spec
+
+	policy := &Policy{
+		Name:       imageUpdatePolicy.Name,
+		Ignore:     spec.Ignore,
+		Alert:      spec.Alert,
+		namespaces: spec.Selector.Namespaces,
+		variants:   spec.Variants,
+	}
+
+	for _, repository := range spec.Selector.Repositories {
+		policy.repositories = append(policy.repositories, compileRepositoryPattern(repository))
+	}
+
+	policy.labelSelector = labels.Everything()
+
+	if spec.Selector.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(spec.Selector.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: invalid label selector: %w", policy.Name, err)
+		}
+
+		policy.labelSelector = selector
+	}
+
+	if spec.PinMode != "" {
+		pinMode, err := version.ParsePinMode(spec.PinMode)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", policy.Name, err)
+		}
+
+		policy.pinMode = &pinMode
+	}
+
+	if spec.Scheme != "" {
+		scheme, err := version.ParseScheme(spec.Scheme)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", policy.Name, err)
+		}
+
+		policy.scheme = &scheme
+	}
+
+	var err error
+
+	policy.constraint, err = version.ParseConstraint(spec.Constraint)
+	if err != nil {
+		return nil, fmt.Errorf("policy %s: %w", policy.Name, err)
+	}
+
+	policy.includeTags, err = version.ParseTagFilter(spec.IncludeTags)
+	if err != nil {
+		return nil, fmt.Errorf("policy %s: %w", policy.Name, err)
+	}
+
+	policy.excludeTags, err = version.ParseTagFilter(spec.ExcludeTags)
+	if err != nil {
+		return nil, fmt.Errorf("policy %s: %w", policy.Name, err)
+	}
+
+	return policy, nil
+}
+
+// Apply overrides the settings of a version policy that are set in this policy.
+func (p *Policy) Apply(base version.Policy) version.Policy {
+	if p.pinMode != nil {
+		base.PinMode = *p.pinMode
+	}
+
+	if p.scheme != nil {
+		base.Scheme = *p.scheme
+	}
+
+	if p.constraint != nil {
+		base.Constraint = p.constraint
+	}
+
+	if p.includeTags != nil {
+		base.IncludeTags = p.includeTags
+	}
+
+	if p.excludeTags != nil {
+		base.ExcludeTags = p.excludeTags
+	}
+
+	if p.variants != nil {
+		base.Variants = *p.variants
+	}
+
+	return base
+}
+
+// Violated reports whether an image this many versions behind exceeds the alert thresholds.
+func (p *Policy) Violated(major, minor, patch int64) bool {
+	return p.Alert.Exceeded(major, minor, patch)
+}
+
+// match returns whether the policy selects the image and how specific the match is.
+func (p *Policy) match(containerImage clients.ContainerImage) (specificity, bool) {
+	var score specificity
+
+	if len(p.namespaces) > 0 {
+		namespace, _ := containerImage.Metadata[clients.MetadataNamespace].(string)
+		if !slices.Contains(p.namespaces, namespace) {
+			return specificity{}, false
+		}
+
+		score.namespaces = true
+	}
+
+	if !p.labelSelector.Matches(labels.Set(containerImage.Labels)) {
+		return specificity{}, false
+	}
+
+	requirements, _ := p.labelSelector.Requirements()
+	score.labels = len(requirements)
+
+	if len(p.repositories) == 0 {
+		return score, true
+	}
+
+	ref, err := name.ParseReference(containerImage.Image)
+	if err != nil {
+		return specificity{}, false
+	}
+
+	repository := ref.Context().Name()
+	matched := false
+
+	for _, pattern := range p.repositories {
+		if pattern.pattern.MatchString(repository) {
+			matched = true
+			score.repository = max(score.repository, pattern.literals)
+		}
+	}
+
+	return score, matched
+}
+
+// specificity ranks matching policies. A longer repository pattern wins over a namespace restriction, which wins
+// over more label requirements.
+type specificity struct {
+	repository int
+	namespaces bool
+	labels     int
+}
+
+func (s specificity) compare(other specificity) int {
+	if s.repository != other.repository {
+		return s.repository - other.repository
+	}
+
+	if s.namespaces != other.namespaces {
+		if s.namespaces {
+			return 1
+		}
+
+		return -1
+	}
+
+	return s.labels - other.labels
+}
+
+// compileRepositoryPattern turns a repository glob into a regular expression matching normalized repository
+// names, e.g. nginx into index.docker.io/library/nginx.
+func compileRepositoryPattern(pattern string) repositoryPattern {
+	first, _, hasPath := strings.Cut(pattern, "/")
+
+	switch {
+	case first == "docker.io":
+		pattern = name.DefaultRegistry + strings.TrimPrefix(pattern, "docker.io")
+	case !strings.ContainsAny(first, ".:") && first != "localhost" && first != "*" && first != "**":
+		if !hasPath {
+			pattern = "library/" + pattern
+		}
+
+		pattern = name.DefaultRegistry + "/" + pattern
+	}
+
+	var expression strings.Builder
+
+	literals := 0
+
+	expression.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expression.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expression.WriteString("[^/]")
+		default:
+			expression.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			literals++
+		}
+	}
+
+	expression.WriteString("$")
+
+	return repositoryPattern{
+		pattern:  regexp.MustCompile(expression.String()),
+		literals: literals,
+	}
+}
@@ -0,0 +1,142 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/policy"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+func newPolicy(t *testing.T, name string, spec policy.Spec) *policy.Policy {
+	t.Helper()
+
+	compiled, err := policy.Compile(&policy.ImageUpdatePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       spec,
+	})
+	require.NoError(t, err)
+
+	return compiled
+}
+
+func image(image, namespace string, labels map[string]string) clients.ContainerImage {
+	return clients.ContainerImage{
+		Image:    image,
+		Labels:   labels,
+		Metadata: map[string]interface{}{clients.MetadataNamespace: namespace},
+	}
+}
+
+func TestStore_Match(t *testing.T) {
+	store := policy.NewStore()
+	store.Set(newPolicy(t, "everything", policy.Spec{}))
+	store.Set(newPolicy(t, "prod", policy.Spec{
+		Selector: policy.Selector{Namespaces: []string{"prod"}},
+	}))
+	store.Set(newPolicy(t, "frontend", policy.Spec{
+		Selector: policy.Selector{
+			Namespaces: []string{"prod"},
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "frontend"},
+			},
+		},
+	}))
+	store.Set(newPolicy(t, "official", policy.Spec{
+		Selector: policy.Selector{Repositories: []string{"docker.io/library/*"}},
+	}))
+	store.Set(newPolicy(t, "nginx", policy.Spec{
+		Selector: policy.Selector{Repositories: []string{"nginx"}},
+	}))
+	store.Set(newPolicy(t, "org", policy.Spec{
+		Selector: policy.Selector{Repositories: []string{"ghcr.io/org/**"}},
+	}))
+
+	for _, testCase := range []struct {
+		image    clients.ContainerImage
+		expected string
+	}{
+		{image("quay.io/prometheus/node-exporter:v1.7.0", "monitoring", nil), "everything"},
+		{image("quay.io/prometheus/node-exporter:v1.7.0", "prod", nil), "prod"},
+		{image("quay.io/prometheus/node-exporter:v1.7.0", "prod", map[string]string{"team": "frontend"}), "frontend"},
+		{image("postgres:16.1", "prod", map[string]string{"team": "frontend"}), "official"},
+		{image("docker.io/library/nginx:1.25", "prod", nil), "nginx"},
+		{image("ghcr.io/org/team/app:1.0.0", "dev", nil), "org"},
+		{image("ghcr.io/organisation/app:1.0.0", "dev", nil), "everything"},
+	} {
+		matched, ok := store.Match(testCase.image)
+		require.True(t, ok, testCase.image.Image)
+		require.Equal(t, testCase.expected, matched.Name, testCase.image.Image)
+	}
+
+	store.Delete("everything")
+
+	_, ok := store.Match(image("quay.io/prometheus/node-exporter:v1.7.0", "monitoring", nil))
+	require.False(t, ok)
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	variants := true
+	compiled := newPolicy(t, "alpine", policy.Spec{
+		PinMode:     "minor",
+		Scheme:      "loose",
+		IncludeTags: "-alpine$",
+		Variants:    &variants,
+	})
+
+	constraint, err := version.ParseConstraint("< 2.0")
+	require.NoError(t, err)
+
+	applied := compiled.Apply(version.Policy{PinMode: version.PIN_MAJOR, Constraint: constraint})
+	require.Equal(t, version.PIN_MINOR, applied.PinMode)
+	require.Equal(t, version.SchemeLoose, applied.Scheme)
+	require.Equal(t, constraint, applied.Constraint)
+	require.True(t, applied.IncludeTags.MatchString("1.25-alpine"))
+	require.Nil(t, applied.ExcludeTags)
+	require.True(t, applied.Variants)
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, spec := range []policy.Spec{
+		{PinMode: "patch"},
+		{Scheme: "calver"},
+		{Constraint: "newest"},
+		{ExcludeTags: "("},
+		{Selector: policy.Selector{LabelSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Near"}},
+		}}},
+	} {
+		_, err := policy.Compile(&policy.ImageUpdatePolicy{ObjectMeta: metav1.ObjectMeta{Name: "broken"}, Spec: spec})
+		require.ErrorContains(t, err, "policy broken:", spec)
+	}
+}
+
+func TestPolicy_Violated(t *testing.T) {
+	compiled := newPolicy(t, "thresholds", policy.Spec{
+		Alert: &policy.Thresholds{Major: 1, Minor: 3},
+	})
+
+	require.False(t, compiled.Violated(0, 2, 5))
+	require.True(t, compiled.Violated(0, 3, 0))
+	require.True(t, compiled.Violated(1, 0, 0))
+	require.False(t, newPolicy(t, "none", policy.Spec{}).Violated(5, 0, 0))
+}
+
+func TestParseThresholds(t *testing.T) {
+	thresholds, err := policy.ParseThresholds("major=1, minor=3")
+	require.NoError(t, err)
+	require.Equal(t, &policy.Thresholds{Major: 1, Minor: 3}, thresholds)
+
+	thresholds, err = policy.ParseThresholds("")
+	require.NoError(t, err)
+	require.Nil(t, thresholds)
+	require.False(t, thresholds.Exceeded(10, 0, 0))
+
+	for _, invalid := range []string{"major", "major=-1", "build=1", "minor=x"} {
+		_, err = policy.ParseThresholds(invalid)
+		require.Error(t, err, invalid)
+	}
+}
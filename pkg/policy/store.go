@@ -0,0 +1,58 @@
+package policy
+
+import (
+	"sync"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+)
+
+// Store holds the current policies. It is filled by the informer and read by the evaluator.
+type Store struct {
+	mutex    sync.RWMutex
+	policies map[string]*Policy
+}
+
+func NewStore() *Store {
+	return &Store{
+		policies: map[string]*Policy{},
+	}
+}
+
+func (s *Store) Set(policy *Policy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.policies[policy.Name] = policy
+}
+
+func (s *Store) Delete(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.policies, name)
+}
+
+// Match returns the most specific policy selecting the image. Equally specific policies are ordered by name.
+func (s *Store) Match(containerImage clients.ContainerImage) (*Policy, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var (
+		best      *Policy
+		bestScore specificity
+	)
+
+	for _, policy := range s.policies {
+		score, ok := policy.match(containerImage)
+		if !ok {
+			continue
+		}
+
+		comparison := score.compare(bestScore)
+		if best == nil || comparison > 0 || comparison == 0 && policy.Name < best.Name {
+			best, bestScore = policy, score
+		}
+	}
+
+	return best, best != nil
+}
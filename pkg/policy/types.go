@@ -0,0 +1,112 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var GroupVersionResource = schema.GroupVersionResource{
+	Group:    "outdated-images.patrick246.de",
+	Version:  "v1alpha1",
+	Resource: "imageupdatepolicies",
+}
+
+// ImageUpdatePolicy is a cluster-scoped custom resource setting the update policy of the images it selects.
+type ImageUpdatePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec Spec `json:"spec"`
+}
+
+type Spec struct {
+	Selector Selector `json:"selector,omitempty"`
+
+	// PinMode, Constraint and Scheme override the default policy if set
+	PinMode    string `json:"pinMode,omitempty"`
+	Constraint string `json:"constraint,omitempty"`
+	Scheme     string `json:"scheme,omitempty"`
+
+	// IncludeTags and ExcludeTags are regular expressions filtering the tags considered for an update
+	IncludeTags string `json:"includeTags,omitempty"`
+	ExcludeTags string `json:"excludeTags,omitempty"`
+
+	// Variants treats a suffix shared with the current tag, like the alpine of 1.25-alpine, as image variant
+	// instead of a prerelease
+	Variants *bool `json:"variants,omitempty"`
+
+	// Ignore skips the selected images, they are neither checked nor exported
+	Ignore bool `json:"ignore,omitempty"`
+
+	Alert *Thresholds `json:"alert,omitempty"`
+}
+
+// Selector selects images by all of its fields, empty fields select everything.
+type Selector struct {
+	// Repositories are globs like docker.io/library/* or ghcr.io/org/**. * matches within a path component, **
+	// across components. Repositories without a registry refer to Docker Hub.
+	Repositories []string `json:"repositories,omitempty"`
+
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// Thresholds mark an image as violating the policy once it is at least this many versions behind. A newer major
+// version also exceeds the minor and patch thresholds, a newer minor version the patch threshold. Zero disables a
+// threshold.
+type Thresholds struct {
+	Major int64 `json:"major,omitempty"`
+	Minor int64 `json:"minor,omitempty"`
+	Patch int64 `json:"patch,omitempty"`
+}
+
+// Exceeded reports whether an image this many versions behind reaches one of the thresholds. Nil thresholds are
+// never exceeded.
+func (t *Thresholds) Exceeded(major, minor, patch int64) bool {
+	if t == nil {
+		return false
+	}
+
+	return t.Major > 0 && major >= t.Major ||
+		t.Minor > 0 && (major > 0 || minor >= t.Minor) ||
+		t.Patch > 0 && (major > 0 || minor > 0 || patch >= t.Patch)
+}
+
+// ParseThresholds reads thresholds written like major=1,minor=3. An empty string returns nil.
+func ParseThresholds(thresholds string) (*Thresholds, error) {
+	if strings.TrimSpace(thresholds) == "" {
+		return nil, nil
+	}
+
+	var parsed Thresholds
+
+	for _, threshold := range strings.Split(thresholds, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(threshold), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid threshold %q, expected type=count", threshold)
+		}
+
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid threshold %q, expected a non-negative count", threshold)
+		}
+
+		switch key {
+		case "major":
+			parsed.Major = count
+		case "minor":
+			parsed.Minor = count
+		case "patch":
+			parsed.Patch = count
+		default:
+			return nil, fmt.Errorf("invalid threshold %q, expected one of [major, minor, patch]", threshold)
+		}
+	}
+
+	return &parsed, nil
+}
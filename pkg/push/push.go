@@ -0,0 +1,276 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/exporter"
+)
+
+// Target receives the container metrics of an evaluation.
+type Target interface {
+	Push(ctx context.Context, evaluator *evaluation.Evaluator) error
+}
+
+// ValidateGrouping checks that the grouping key doesn't replace the job or a label of the container metrics with the
+// allowed source labels. Overwriting them would merge the series of different containers.
+func ValidateGrouping(grouping map[string]string, metricLabels []string) error {
+	labelNames, err := evaluation.LabelNames(metricLabels)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range sortedKeys(grouping) {
+		switch {
+		case name == "job":
+			return errors.New("grouping label job is set by the job")
+		case strings.HasPrefix(name, "__"):
+			return fmt.Errorf("grouping label %s: label names starting with __ are reserved", name)
+		case slices.Contains(labelNames, name):
+			return fmt.Errorf("grouping label %s collides with the %s label of the container metrics", name, name)
+		}
+	}
+
+	return nil
+}
+
+// Pushgateway replaces the metrics of the job and grouping key on a Prometheus Pushgateway with every push, so
+// containers that are gone disappear.
+type Pushgateway struct {
+	pusherURL string
+	job       string
+	grouping  map[string]string
+	username  string
+	password  string
+	client    *http.Client
+}
+
+// NewPushgateway pushes to the Pushgateway at the URL. Credentials in the URL are sent as basic auth.
+func NewPushgateway(gatewayURL, job string, grouping map[string]string, client *http.Client) (*Pushgateway, error) {
+	parsed, username, password, err := parseURL(gatewayURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pushgateway{
+		pusherURL: parsed,
+		job:       job,
+		grouping:  grouping,
+		username:  username,
+		password:  password,
+		client:    client,
+	}, nil
+}
+
+func (p *Pushgateway) Push(ctx context.Context, evaluator *evaluation.Evaluator) error {
+	pusher := push.New(p.pusherURL, p.job).
+		Collector(exporter.NewCollector(evaluator)).
+		Client(p.client)
+
+	for _, name := range sortedKeys(p.grouping) {
+		pusher = pusher.Grouping(name, p.grouping[name])
+	}
+
+	if p.username != "" {
+		pusher = pusher.BasicAuth(p.username, p.password)
+	}
+
+	return pusher.PushContext(ctx)
+}
+
+// RemoteWrite sends the metrics to a Prometheus remote-write endpoint, e.g. Prometheus with
+// --web.enable-remote-write-receiver, Mimir or Thanos. The job and grouping key become labels of every series.
+type RemoteWrite struct {
+	writeURL string
+	labels   map[string]string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewRemoteWrite writes to the endpoint at the URL. Credentials in the URL are sent as basic auth.
+func NewRemoteWrite(writeURL, job string, grouping map[string]string, client *http.Client) (*RemoteWrite, error) {
+	parsed, username, password, err := parseURL(writeURL)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{"job": job}
+	for name, value := range grouping {
+		labels[name] = value
+	}
+
+	return &RemoteWrite{
+		writeURL: parsed,
+		labels:   labels,
+		username: username,
+		password: password,
+		client:   client,
+	}, nil
+}
+
+func (r *RemoteWrite) Push(ctx context.Context, evaluator *evaluation.Evaluator) error {
+	body := snappy.Encode(nil, EncodeWriteRequest(evaluator.Series(), r.labels, evaluation.MetricHelp(), time.Now()))
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, r.writeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/x-protobuf")
+	request.Header.Set("Content-Encoding", "snappy")
+	request.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	request.Header.Set("User-Agent", "outdated-image-exporter")
+
+	if r.username != "" {
+		request.SetBasicAuth(r.username, r.password)
+	}
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+
+		return fmt.Errorf("remote write to %s failed with status %d: %s", r.writeURL, response.StatusCode, bytes.TrimSpace(message))
+	}
+
+	return nil
+}
+
+// Fields of the remote-write protobuf messages
+const (
+	writeRequestTimeseries = 1
+	writeRequestMetadata   = 3
+
+	timeSeriesLabels  = 1
+	timeSeriesSamples = 2
+
+	labelName  = 1
+	labelValue = 2
+
+	sampleValue     = 1
+	sampleTimestamp = 2
+
+	metadataType             = 1
+	metadataMetricFamilyName = 2
+	metadataHelp             = 4
+
+	metricTypeGauge = 2
+)
+
+// EncodeWriteRequest encodes the series as a remote-write WriteRequest with one gauge sample each at the given time.
+// Labels are added to every series, empty labels are left out like Prometheus does.
+func EncodeWriteRequest(series []evaluation.Metric, labels map[string]string, help map[string]string, now time.Time) []byte {
+	var request []byte
+
+	for _, metric := range series {
+		seriesLabels := map[string]string{}
+
+		for name, value := range metric.Labels {
+			seriesLabels[name] = value
+		}
+
+		for name, value := range labels {
+			seriesLabels[name] = value
+		}
+
+		seriesLabels["__name__"] = metric.Name
+
+		var timeSeries []byte
+
+		for _, name := range sortedKeys(seriesLabels) {
+			if seriesLabels[name] == "" {
+				continue
+			}
+
+			var label []byte
+			label = protowire.AppendTag(label, labelName, protowire.BytesType)
+			label = protowire.AppendString(label, name)
+			label = protowire.AppendTag(label, labelValue, protowire.BytesType)
+			label = protowire.AppendString(label, seriesLabels[name])
+
+			timeSeries = protowire.AppendTag(timeSeries, timeSeriesLabels, protowire.BytesType)
+			timeSeries = protowire.AppendBytes(timeSeries, label)
+		}
+
+		var sample []byte
+		sample = protowire.AppendTag(sample, sampleValue, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(metric.Value))
+		sample = protowire.AppendTag(sample, sampleTimestamp, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(now.UnixMilli()))
+
+		timeSeries = protowire.AppendTag(timeSeries, timeSeriesSamples, protowire.BytesType)
+		timeSeries = protowire.AppendBytes(timeSeries, sample)
+
+		request = protowire.AppendTag(request, writeRequestTimeseries, protowire.BytesType)
+		request = protowire.AppendBytes(request, timeSeries)
+	}
+
+	for _, name := range sortedKeys(help) {
+		var metadata []byte
+		metadata = protowire.AppendTag(metadata, metadataType, protowire.VarintType)
+		metadata = protowire.AppendVarint(metadata, metricTypeGauge)
+		metadata = protowire.AppendTag(metadata, metadataMetricFamilyName, protowire.BytesType)
+		metadata = protowire.AppendString(metadata, name)
+		metadata = protowire.AppendTag(metadata, metadataHelp, protowire.BytesType)
+		metadata = protowire.AppendString(metadata, help[name])
+
+		request = protowire.AppendTag(request, writeRequestMetadata, protowire.BytesType)
+		request = protowire.AppendBytes(request, metadata)
+	}
+
+	return request
+}
+
+// parseURL splits the credentials off an URL.
+func parseURL(rawURL string) (string, string, string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", "", "", fmt.Errorf("%q must be an http or https URL", rawURL)
+	}
+
+	var username, password string
+
+	if parsed.User != nil {
+		username = parsed.User.Username()
+		password, _ = parsed.User.Password()
+		parsed.User = nil
+	}
+
+	return parsed.String(), username, password, nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
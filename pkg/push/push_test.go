@@ -0,0 +1,235 @@
+package push_test
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/push"
+)
+
+// newEvaluator checks a container whose registry can't be reached, so it only has a check status.
+func newEvaluator(t *testing.T) *evaluation.Evaluator {
+	client := make(evaluationtest.Client, 1)
+	client <- clients.ContainerImage{
+		Action: clients.ContainerImageAdded,
+		Name:   "file:deploy.yaml/web",
+		Image:  evaluationtest.UnreachableHost(t) + "/web:1.0.0",
+	}
+
+	close(client)
+
+	evaluator := evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1})
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	return evaluator
+}
+
+type sample struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+// decodeWriteRequest reads the time series of a WriteRequest and the names of its metadata.
+func decodeWriteRequest(t *testing.T, request []byte) ([]sample, []string) {
+	var (
+		samples  []sample
+		metadata []string
+	)
+
+	forEachField(t, request, func(number protowire.Number, value []byte) {
+		switch number {
+		case 1:
+			series := sample{labels: map[string]string{}}
+
+			forEachField(t, value, func(number protowire.Number, value []byte) {
+				switch number {
+				case 1:
+					var name string
+
+					forEachField(t, value, func(number protowire.Number, value []byte) {
+						if number == 1 {
+							name = string(value)
+						} else {
+							series.labels[name] = string(value)
+						}
+					})
+				case 2:
+					forEachField(t, value, func(number protowire.Number, value []byte) {
+						if number == 1 {
+							bits, _ := protowire.ConsumeFixed64(value)
+							series.value = math.Float64frombits(bits)
+						} else {
+							timestamp, _ := protowire.ConsumeVarint(value)
+							series.timestamp = int64(timestamp)
+						}
+					})
+				}
+			})
+
+			samples = append(samples, series)
+		case 3:
+			forEachField(t, value, func(number protowire.Number, value []byte) {
+				if number == 2 {
+					metadata = append(metadata, string(value))
+				}
+			})
+		}
+	})
+
+	return samples, metadata
+}
+
+// forEachField calls fn with the raw value of every field, varints and fixed64 values stay encoded.
+func forEachField(t *testing.T, message []byte, fn func(protowire.Number, []byte)) {
+	for len(message) > 0 {
+		number, fieldType, n := protowire.ConsumeTag(message)
+		require.GreaterOrEqual(t, n, 0)
+		message = message[n:]
+
+		var value []byte
+
+		switch fieldType {
+		case protowire.BytesType:
+			bytesValue, n := protowire.ConsumeBytes(message)
+			require.GreaterOrEqual(t, n, 0)
+			value, message = bytesValue, message[n:]
+		case protowire.VarintType, protowire.Fixed64Type:
+			n := protowire.ConsumeFieldValue(number, fieldType, message)
+			require.GreaterOrEqual(t, n, 0)
+			value, message = message[:n], message[n:]
+		default:
+			t.Fatalf("unexpected field type %d", fieldType)
+		}
+
+		fn(number, value)
+	}
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+
+	request := push.EncodeWriteRequest([]evaluation.Metric{{
+		Name:   "container_image_outdated",
+		Labels: map[string]string{"type": "major", "image": "nginx:1.24.0", "namespace": ""},
+		Value:  2,
+	}}, map[string]string{"job": "ci", "runner": "gitlab"}, map[string]string{"container_image_outdated": "help"}, now)
+
+	samples, metadata := decodeWriteRequest(t, request)
+	require.Equal(t, []sample{{
+		labels: map[string]string{
+			"__name__": "container_image_outdated",
+			"type":     "major",
+			"image":    "nginx:1.24.0",
+			"job":      "ci",
+			"runner":   "gitlab",
+		},
+		value:     2,
+		timestamp: 1700000000000,
+	}}, samples)
+	require.Equal(t, []string{"container_image_outdated"}, metadata)
+}
+
+func TestRemoteWrite_Push(t *testing.T) {
+	var samples []sample
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		require.Equal(t, http.MethodPost, request.Method)
+		require.Equal(t, "/api/v1/write", request.URL.Path)
+		require.Equal(t, "snappy", request.Header.Get("Content-Encoding"))
+		require.Equal(t, "0.1.0", request.Header.Get("X-Prometheus-Remote-Write-Version"))
+
+		username, password, ok := request.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "ci", username)
+		require.Equal(t, "secret", password)
+
+		compressed, err := io.ReadAll(request.Body)
+		require.NoError(t, err)
+
+		body, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+
+		samples, _ = decodeWriteRequest(t, body)
+
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	target, err := push.NewRemoteWrite(strings.Replace(server.URL, "http://", "http://ci:secret@", 1)+"/api/v1/write", "scan", map[string]string{"runner": "ci"}, server.Client())
+	require.NoError(t, err)
+	require.NoError(t, target.Push(context.Background(), newEvaluator(t)))
+
+	require.Len(t, samples, 1)
+	require.Equal(t, "container_image_check_status", samples[0].labels["__name__"])
+	require.Equal(t, "scan", samples[0].labels["job"])
+	require.Equal(t, "ci", samples[0].labels["runner"])
+	require.Equal(t, "file:deploy.yaml/web", samples[0].labels["container"])
+	require.Equal(t, 1.0, samples[0].value)
+}
+
+func TestRemoteWrite_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		http.Error(writer, "out of order sample", http.StatusBadRequest)
+	}))
+	t.Cleanup(server.Close)
+
+	target, err := push.NewRemoteWrite(server.URL, "scan", nil, server.Client())
+	require.NoError(t, err)
+	require.ErrorContains(t, target.Push(context.Background(), newEvaluator(t)), "status 400: out of order sample")
+}
+
+func TestPushgateway_Push(t *testing.T) {
+	var body string
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		require.Equal(t, http.MethodPut, request.Method)
+		require.Equal(t, "/metrics/job/scan/runner/ci", request.URL.Path)
+
+		content, err := io.ReadAll(request.Body)
+		require.NoError(t, err)
+
+		body = string(content)
+
+		writer.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	target, err := push.NewPushgateway(server.URL, "scan", map[string]string{"runner": "ci"}, server.Client())
+	require.NoError(t, err)
+	require.NoError(t, target.Push(context.Background(), newEvaluator(t)))
+
+	require.Contains(t, body, "container_image_check_status")
+}
+
+func TestNewRemoteWrite_InvalidURL(t *testing.T) {
+	_, err := push.NewRemoteWrite("prometheus:9090", "scan", nil, http.DefaultClient)
+	require.Error(t, err)
+}
+
+func TestValidateGrouping(t *testing.T) {
+	require.NoError(t, push.ValidateGrouping(map[string]string{"runner": "ci", "pipeline": "web"}, []string{"team"}))
+
+	for grouping, expected := range map[string]string{
+		"namespace": "grouping label namespace collides with the namespace label of the container metrics",
+		"type":      "grouping label type collides with the type label of the container metrics",
+		"team":      "grouping label team collides with the team label of the container metrics",
+		"job":       "grouping label job is set by the job",
+		"__name__":  "grouping label __name__: label names starting with __ are reserved",
+	} {
+		require.EqualError(t, push.ValidateGrouping(map[string]string{grouping: "ci"}, []string{"team"}), expected, grouping)
+	}
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+// writeJUnit writes every image as a test case. Outdated images are failures, images that could not be checked
+// are errors.
+func writeJUnit(writer io.Writer, results []evaluation.Result) error {
+	suite := junitTestSuite{
+		Name: toolName,
+	}
+
+	for _, result := range results {
+		testCase := junitTestCase{
+			Name:      result.ContainerImage.Name,
+			ClassName: result.ContainerImage.Image,
+		}
+
+		switch {
+		case result.Err != nil:
+			testCase.Error = &junitMessage{Message: result.Err.Error(), Type: ruleCheckFailed}
+			suite.Errors++
+		case result.Severity() != evaluation.SeverityNone:
+			testCase.Failure = &junitMessage{Message: Describe(result), Type: result.Severity().String()}
+			suite.Failures++
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.Tests++
+	}
+
+	_, err := io.WriteString(writer, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+
+	err = encoder.Encode(junitTestSuites{
+		Name:     toolName,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Suites:   []junitTestSuite{suite},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(writer, "\n")
+
+	return err
+}
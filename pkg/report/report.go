@@ -0,0 +1,146 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+)
+
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatSARIF Format = "sarif"
+	FormatJUnit Format = "junit"
+)
+
+func ParseFormat(format string) (Format, error) {
+	switch Format(format) {
+	case FormatTable, FormatJSON, FormatSARIF, FormatJUnit:
+		return Format(format), nil
+	}
+
+	return "", fmt.Errorf("unknown output format %q, expected one of [table, json, sarif, junit]", format)
+}
+
+// Write renders the results of a scan in the given format.
+func Write(writer io.Writer, format Format, results []evaluation.Result) error {
+	switch format {
+	case FormatTable:
+		return writeTable(writer, results)
+	case FormatJSON:
+		return writeJSON(writer, results)
+	case FormatSARIF:
+		return writeSARIF(writer, results)
+	case FormatJUnit:
+		return writeJUnit(writer, results)
+	}
+
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+func writeTable(writer io.Writer, results []evaluation.Result) error {
+	tableWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+
+	_, err := fmt.Fprintln(tableWriter, "NAME\tIMAGE\tMAJOR\tMINOR\tPATCH\tSTATUS")
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		status := result.Severity().String()
+		if result.Err != nil {
+			status = "error: " + result.Err.Error()
+		}
+
+		_, err = fmt.Fprintf(tableWriter, "%s\t%s\t%d\t%d\t%d\t%s\n",
+			result.ContainerImage.Name,
+			result.ContainerImage.Image,
+			result.Major,
+			result.Minor,
+			result.Patch,
+			status,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tableWriter.Flush()
+}
+
+type jsonResult struct {
+	Name     string            `json:"name"`
+	Image    string            `json:"image"`
+	Current  string            `json:"current,omitempty"`
+	Major    int64             `json:"major"`
+	Minor    int64             `json:"minor"`
+	Patch    int64             `json:"patch"`
+	Severity string            `json:"severity"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Policy   string            `json:"policy,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// writeJSON writes one JSON object per line.
+func writeJSON(writer io.Writer, results []evaluation.Result) error {
+	encoder := json.NewEncoder(writer)
+
+	for _, result := range results {
+		output := jsonResult{
+			Name:     result.ContainerImage.Name,
+			Image:    result.ContainerImage.Image,
+			Current:  result.Current,
+			Major:    result.Major,
+			Minor:    result.Minor,
+			Patch:    result.Patch,
+			Severity: result.Severity().String(),
+			Labels:   result.ContainerImage.Labels,
+			Policy:   result.Policy,
+		}
+
+		if result.Err != nil {
+			output.Error = result.Err.Error()
+		}
+
+		err := encoder.Encode(output)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// location returns where a result was found, if its source is a file.
+func location(result evaluation.Result) (file string, line, column int, ok bool) {
+	file, ok = result.ContainerImage.Metadata[clients.MetadataFile].(string)
+	if !ok {
+		return "", 0, 0, false
+	}
+
+	line, _ = result.ContainerImage.Metadata[clients.MetadataLine].(int)
+	column, _ = result.ContainerImage.Metadata[clients.MetadataColumn].(int)
+
+	return file, line, column, true
+}
+
+// Describe summarizes how far an image is behind in one sentence fragment.
+func Describe(result evaluation.Result) string {
+	switch result.Severity() {
+	case evaluation.SeverityMajor:
+		return result.ContainerImage.Image + " is " + strconv.FormatInt(result.Major, 10) + " major version(s) behind"
+	case evaluation.SeverityMinor:
+		return result.ContainerImage.Image + " is " + strconv.FormatInt(result.Minor, 10) + " minor version(s) behind"
+	case evaluation.SeverityPatch:
+		return result.ContainerImage.Image + " is " + strconv.FormatInt(result.Patch, 10) + " patch version(s) behind"
+	}
+
+	return result.ContainerImage.Image + " is up-to-date"
+}
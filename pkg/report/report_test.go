@@ -0,0 +1,94 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/report"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+var results = []evaluation.Result{{
+	ContainerImage: clients.ContainerImage{
+		Name:  "deploy.yaml:[0].spec.template.spec.containers[0].image",
+		Image: "nginx:1.24.0",
+		Metadata: map[string]interface{}{
+			clients.MetadataFile:   "deploy.yaml",
+			clients.MetadataLine:   12,
+			clients.MetadataColumn: 18,
+		},
+	},
+	Evaluation: version.Evaluation{
+		Current: "1.24.0",
+		Minor:   2,
+	},
+}, {
+	ContainerImage: clients.ContainerImage{Name: "default/web/app", Image: "redis:7.2.4"},
+	Evaluation:     version.Evaluation{Current: "7.2.4"},
+}, {
+	ContainerImage: clients.ContainerImage{Name: "default/web/broken", Image: "private/image:1.0.0"},
+	Err:            errors.New("unauthorized"),
+}}
+
+func TestWrite_JSON(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, report.Write(&buffer, report.FormatJSON, results))
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.Equal(t, "minor", first["severity"])
+	require.Equal(t, float64(2), first["minor"])
+}
+
+func TestWrite_SARIF(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, report.Write(&buffer, report.FormatSARIF, results))
+
+	var log struct {
+		Runs []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &log))
+	require.Len(t, log.Runs, 1)
+	require.Len(t, log.Runs[0].Results, 2)
+
+	outdated := log.Runs[0].Results[0]
+	require.Equal(t, "outdated-image-minor", outdated.RuleID)
+	require.Equal(t, "warning", outdated.Level)
+	require.Equal(t, "deploy.yaml", outdated.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	require.Equal(t, 12, outdated.Locations[0].PhysicalLocation.Region.StartLine)
+
+	require.Equal(t, "image-check-failed", log.Runs[0].Results[1].RuleID)
+}
+
+func TestWrite_JUnit(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, report.Write(&buffer, report.FormatJUnit, results))
+
+	require.Contains(t, buffer.String(), `<testsuites name="outdated-image-exporter" tests="3" failures="1" errors="1">`)
+	require.Contains(t, buffer.String(), `<failure message="nginx:1.24.0 is 2 minor version(s) behind" type="minor"></failure>`)
+}
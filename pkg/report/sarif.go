@@ -0,0 +1,144 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "outdated-image-exporter"
+	toolURI      = "https://github.com/patrick246/k8s-outdated-image-exporter"
+
+	ruleCheckFailed = "image-check-failed"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+var sarifRules = []sarifRule{{
+	ID:               "outdated-image-" + evaluation.SeverityMajor.String(),
+	ShortDescription: sarifMessage{Text: "A newer major version of the image is available"},
+}, {
+	ID:               "outdated-image-" + evaluation.SeverityMinor.String(),
+	ShortDescription: sarifMessage{Text: "A newer minor version of the image is available"},
+}, {
+	ID:               "outdated-image-" + evaluation.SeverityPatch.String(),
+	ShortDescription: sarifMessage{Text: "A newer patch version of the image is available"},
+}, {
+	ID:               ruleCheckFailed,
+	ShortDescription: sarifMessage{Text: "The image could not be checked for newer versions"},
+}}
+
+// writeSARIF writes outdated images and failed checks as SARIF 2.1.0, e.g. for GitHub code scanning. Up-to-date
+// images are left out.
+func writeSARIF(writer io.Writer, results []evaluation.Result) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           toolName,
+			InformationURI: toolURI,
+			Rules:          sarifRules,
+		}},
+		Results: []sarifResult{},
+	}
+
+	for _, result := range results {
+		output := sarifResult{
+			RuleID:  "outdated-image-" + result.Severity().String(),
+			Message: sarifMessage{Text: Describe(result)},
+		}
+
+		switch {
+		case result.Err != nil:
+			output.RuleID = ruleCheckFailed
+			output.Level = "warning"
+			output.Message.Text = result.ContainerImage.Image + " could not be checked: " + result.Err.Error()
+		case result.Severity() == evaluation.SeverityMajor:
+			output.Level = "error"
+		case result.Severity() == evaluation.SeverityMinor:
+			output.Level = "warning"
+		case result.Severity() == evaluation.SeverityPatch:
+			output.Level = "note"
+		default:
+			continue
+		}
+
+		if file, line, column, ok := location(result); ok {
+			physicalLocation := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: file},
+			}
+
+			if line > 0 {
+				physicalLocation.Region = &sarifRegion{StartLine: line, StartColumn: column}
+			}
+
+			output.Locations = []sarifLocation{{PhysicalLocation: physicalLocation}}
+		}
+
+		run.Results = append(run.Results, output)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}
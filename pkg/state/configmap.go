@@ -0,0 +1,106 @@
+package state
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+
+	coreV1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ConfigMapKey holds the gzip compressed JSON snapshot in the ConfigMap's binary data
+const ConfigMapKey = "snapshot.json.gz"
+
+// ConfigMapStore keeps the snapshot in a ConfigMap, so it survives rescheduling to another node. ConfigMaps are
+// limited to 1 MiB, which fits the compressed results of a few thousand containers.
+type ConfigMapStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+func NewConfigMapStore(client kubernetes.Interface, namespace, name string) *ConfigMapStore {
+	return &ConfigMapStore{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+func (c *ConfigMapStore) Load(ctx context.Context) (*Snapshot, error) {
+	configMap, err := c.client.CoreV1().ConfigMaps(c.namespace).Get(ctx, c.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := configMap.BinaryData[ConfigMapKey]
+	if !ok {
+		return nil, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+
+	err = json.NewDecoder(reader).Decode(&snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+func (c *ConfigMapStore) Save(ctx context.Context, snapshot *Snapshot) error {
+	var data bytes.Buffer
+
+	writer := gzip.NewWriter(&data)
+
+	err := json.NewEncoder(writer).Encode(snapshot)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	configMaps := c.client.CoreV1().ConfigMaps(c.namespace)
+
+	configMap, err := configMaps.Get(ctx, c.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, &coreV1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      c.name,
+				Namespace: c.namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "outdated-image-exporter",
+				},
+			},
+			BinaryData: map[string][]byte{ConfigMapKey: data.Bytes()},
+		}, metav1.CreateOptions{})
+
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	configMap.BinaryData = map[string][]byte{ConfigMapKey: data.Bytes()}
+
+	_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+
+	return err
+}
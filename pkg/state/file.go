@@ -0,0 +1,147 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+)
+
+var (
+	bucketMeta     = []byte("meta")
+	bucketResults  = []byte("results")
+	bucketTagLists = []byte("tagLists")
+
+	keySavedAt = []byte("savedAt")
+)
+
+// FileStore keeps the snapshot in a local BoltDB file, one key per container image and repository.
+type FileStore struct {
+	db *bolt.DB
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileStore{db: db}, nil
+}
+
+func (f *FileStore) Close() error {
+	return f.db.Close()
+}
+
+func (f *FileStore) Load(_ context.Context) (*Snapshot, error) {
+	var snapshot *Snapshot
+
+	err := f.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(bucketMeta)
+		if meta == nil {
+			return nil
+		}
+
+		snapshot = &Snapshot{TagLists: map[string]tags.TagList{}}
+
+		err := snapshot.SavedAt.UnmarshalText(meta.Get(keySavedAt))
+		if err != nil {
+			return err
+		}
+
+		err = forEach(tx.Bucket(bucketResults), func(_ []byte, value []byte) error {
+			var result Result
+
+			err := json.Unmarshal(value, &result)
+			snapshot.Results = append(snapshot.Results, result)
+
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		return forEach(tx.Bucket(bucketTagLists), func(key []byte, value []byte) error {
+			var tagList tags.TagList
+
+			err := json.Unmarshal(value, &tagList)
+			snapshot.TagLists[string(key)] = tagList
+
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// Save replaces the stored snapshot in a single transaction.
+func (f *FileStore) Save(_ context.Context, snapshot *Snapshot) error {
+	return f.db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{bucketMeta, bucketResults, bucketTagLists} {
+			err := tx.DeleteBucket(bucket)
+			if err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+				return err
+			}
+		}
+
+		results, err := tx.CreateBucket(bucketResults)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range snapshot.Results {
+			err = putJSON(results, result.Name, result)
+			if err != nil {
+				return err
+			}
+		}
+
+		tagLists, err := tx.CreateBucket(bucketTagLists)
+		if err != nil {
+			return err
+		}
+
+		for repository, tagList := range snapshot.TagLists {
+			err = putJSON(tagLists, repository, tagList)
+			if err != nil {
+				return err
+			}
+		}
+
+		meta, err := tx.CreateBucket(bucketMeta)
+		if err != nil {
+			return err
+		}
+
+		savedAt, err := snapshot.SavedAt.MarshalText()
+		if err != nil {
+			return err
+		}
+
+		return meta.Put(keySavedAt, savedAt)
+	})
+}
+
+func putJSON(bucket *bolt.Bucket, key string, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return bucket.Put([]byte(key), encoded)
+}
+
+func forEach(bucket *bolt.Bucket, fn func(key, value []byte) error) error {
+	if bucket == nil {
+		return nil
+	}
+
+	return bucket.ForEach(fn)
+}
@@ -0,0 +1,98 @@
+package state
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+// saveTimeout bounds the final save on shutdown
+const saveTimeout = 10 * time.Second
+
+// Snapshot is the persisted state of the exporter: the last result of every container image and the last tag
+// listing of every repository.
+type Snapshot struct {
+	SavedAt  time.Time               `json:"savedAt"`
+	Results  []Result                `json:"results"`
+	TagLists map[string]tags.TagList `json:"tagLists"`
+}
+
+// Result is a saved evaluation result together with its metrics. Only metadata that can be serialized is kept,
+// registry credentials are read again when the container is refreshed.
+type Result struct {
+	Name        string            `json:"name"`
+	Image       string            `json:"image"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	Namespace string        `json:"namespace,omitempty"`
+	Workload  *k8s.Workload `json:"workload,omitempty"`
+	Container string        `json:"container,omitempty"`
+	File      string        `json:"file,omitempty"`
+	Line      int           `json:"line,omitempty"`
+	Column    int           `json:"column,omitempty"`
+
+	Evaluation version.Evaluation `json:"evaluation"`
+	Policy     string             `json:"policy,omitempty"`
+	Err        string             `json:"error,omitempty"`
+	Reason     string             `json:"reason,omitempty"`
+	CheckedAt  time.Time          `json:"checkedAt"`
+
+	LastSuccess time.Time `json:"lastSuccess,omitempty"`
+
+	Metrics []Metric `json:"metrics,omitempty"`
+}
+
+type Metric struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+// Store persists snapshots. Load returns nil if nothing was saved yet.
+type Store interface {
+	Load(ctx context.Context) (*Snapshot, error)
+	Save(ctx context.Context, snapshot *Snapshot) error
+}
+
+// Source provides the snapshots to save.
+type Source interface {
+	Snapshot() *Snapshot
+}
+
+// Persist saves a snapshot of the source every interval and a last one when the context is done. It returns after
+// the last save.
+func Persist(ctx context.Context, store Store, source Source, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			save(ctx, store, source, logger)
+		case <-ctx.Done():
+			saveCtx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+			save(saveCtx, store, source, logger)
+			cancel()
+
+			return
+		}
+	}
+}
+
+func save(ctx context.Context, store Store, source Source, logger *slog.Logger) {
+	snapshot := source.Snapshot()
+
+	err := store.Save(ctx, snapshot)
+	if err != nil {
+		logger.Error("error saving state", "error", err)
+
+		return
+	}
+
+	logger.Debug("saved state", "results", len(snapshot.Results), "repositories", len(snapshot.TagLists))
+}
@@ -0,0 +1,144 @@
+package state_test
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/state"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+func snapshot(latest string) *state.Snapshot {
+	savedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	return &state.Snapshot{
+		SavedAt: savedAt,
+		Results: []state.Result{{
+			Name:      "prod/web-1/nginx",
+			Image:     "nginx:1.24.0",
+			Labels:    map[string]string{"app": "web"},
+			Namespace: "prod",
+			Workload:  &k8s.Workload{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "prod", Name: "web"},
+			Container: "nginx",
+			Evaluation: version.Evaluation{
+				Current:    "1.24.0",
+				Minor:      1,
+				Latest:     latest,
+				Candidates: []string{latest},
+			},
+			CheckedAt: savedAt,
+			Metrics: []state.Metric{{
+				Name:   "container_image_outdated",
+				Labels: map[string]string{"container": "prod/web-1/nginx", "type": "minor"},
+				Value:  1,
+			}},
+		}},
+		TagLists: map[string]tags.TagList{
+			"index.docker.io/library/nginx": {Tags: []string{"1.24.0", latest}, ListedAt: savedAt},
+		},
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+
+	store, err := state.NewFileStore(path)
+	require.NoError(t, err)
+
+	loaded, err := store.Load(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, loaded)
+
+	require.NoError(t, store.Save(context.Background(), snapshot("1.25.0")))
+	require.NoError(t, store.Save(context.Background(), snapshot("1.25.3")))
+	require.NoError(t, store.Close())
+
+	store, err = state.NewFileStore(path)
+	require.NoError(t, err)
+
+	defer store.Close()
+
+	loaded, err = store.Load(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, snapshot("1.25.3"), loaded)
+}
+
+func TestConfigMapStore(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	store := state.NewConfigMapStore(client, "monitoring", "state")
+
+	loaded, err := store.Load(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, loaded)
+
+	require.NoError(t, store.Save(context.Background(), snapshot("1.25.0")))
+	require.NoError(t, store.Save(context.Background(), snapshot("1.25.3")))
+
+	loaded, err = store.Load(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, snapshot("1.25.3"), loaded)
+}
+
+type countingStore struct {
+	saves atomic.Int32
+}
+
+func (c *countingStore) Load(context.Context) (*state.Snapshot, error) {
+	return nil, nil
+}
+
+func (c *countingStore) Save(context.Context, *state.Snapshot) error {
+	c.saves.Add(1)
+
+	return nil
+}
+
+type staticSource struct{}
+
+func (staticSource) Snapshot() *state.Snapshot {
+	return snapshot("1.25.3")
+}
+
+func TestPersist(t *testing.T) {
+	store := &countingStore{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+
+	go func() {
+		state.Persist(ctx, store, staticSource{}, 10*time.Millisecond, slog.Default())
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		return store.saves.Load() >= 2
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+
+	saves := store.saves.Load()
+
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, saves, store.saves.Load())
+}
+
+func TestPersist_SavesOnShutdown(t *testing.T) {
+	store := &countingStore{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	state.Persist(ctx, store, staticSource{}, time.Hour, slog.Default())
+	require.Equal(t, int32(1), store.saves.Load())
+}
@@ -1,6 +1,8 @@
 package tags
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"github.com/google/go-containerregistry/pkg/authn"
 	coreV1 "k8s.io/api/core/v1"
@@ -24,6 +26,24 @@ func (d DockerConfigKeychain) Resolve(resource authn.Resource) (authn.Authentica
 	return authn.FromConfig(authConfig), nil
 }
 
+// Identity identifies the credentials used for a resource without revealing them. Keychains with the same identity
+// authenticate the same way, anonymous access has an empty identity.
+func (d DockerConfigKeychain) Identity(resource authn.Resource) string {
+	authConfig, ok := d.authConfigs[resource.RegistryStr()]
+	if !ok {
+		return ""
+	}
+
+	encoded, err := json.Marshal(authConfig)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(encoded)
+
+	return hex.EncodeToString(sum[:16])
+}
+
 func MergeKeychains(keychains ...*DockerConfigKeychain) *DockerConfigKeychain {
 	authConfigs := map[string]authn.AuthConfig{}
 
@@ -94,3 +114,8 @@ func RegistryCredentialsFromSecrets(secrets []*coreV1.Secret) *DockerConfigKeych
 		authConfigs: mergedConfig,
 	}
 }
+
+// KeychainFromAuthConfigs creates a keychain from credentials per registry host.
+func KeychainFromAuthConfigs(authConfigs map[string]authn.AuthConfig) *DockerConfigKeychain {
+	return &DockerConfigKeychain{authConfigs: authConfigs}
+}
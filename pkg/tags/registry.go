@@ -2,41 +2,206 @@ package tags
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/metrics"
 )
 
+// ErrDigestOnly is returned for image references pinned by digest, like nginx@sha256:...
+var ErrDigestOnly = errors.New("image is pinned by digest")
+
+// Options change how registries are queried. They can be replaced at runtime with SetOptions.
+type Options struct {
+	// Rewrites are applied to the repository of an image before its tags are listed
+	Rewrites []Rewrite
+
+	// InsecureRegistries are accessed via plain HTTP
+	InsecureRegistries []string
+}
+
+// transport instruments the requests of every listing with metrics and client spans
+var transport = otelhttp.NewTransport(metrics.InstrumentRoundTripper(remote.DefaultTransport))
+
+var tracer = otel.Tracer("github.com/patrick246/k8s-outdated-image-exporter/pkg/tags")
+
+// TagList is the result of the last successful listing of a repository.
+type TagList struct {
+	Tags     []string  `json:"tags"`
+	ListedAt time.Time `json:"listedAt"`
+}
+
 type TagLister struct {
 	keychain *DockerConfigKeychain
+	options  Options
+	mutex    sync.RWMutex
+
+	// tagLists holds the last listing per repository, before rewrites are applied
+	tagLists      map[string]TagList
+	tagListsMutex sync.RWMutex
 }
 
 func NewTagLister(keychain *DockerConfigKeychain) (*TagLister, error) {
 	return &TagLister{
 		keychain: keychain,
+		tagLists: map[string]TagList{},
 	}, nil
 }
 
+// SetKeychain replaces the credentials used for every image.
+func (t *TagLister) SetKeychain(keychain *DockerConfigKeychain) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.keychain = keychain
+}
+
+func (t *TagLister) SetOptions(options Options) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.options = options
+}
+
+// Identity identifies the credentials of a keychain ListTags would use for an image, after rewrites.
+func (t *TagLister) Identity(image string, keychain *DockerConfigKeychain) string {
+	t.mutex.RLock()
+	rewrites := t.options.Rewrites
+	t.mutex.RUnlock()
+
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return ""
+	}
+
+	repo, err := name.NewRepository(rewrite(ref.Context().Name(), rewrites))
+	if err != nil {
+		return ""
+	}
+
+	return keychain.Identity(repo)
+}
+
 func (t *TagLister) ListTags(ctx context.Context, image string, keychain *DockerConfigKeychain) ([]string, error) {
+	t.mutex.RLock()
+	options := t.options
+	mergedKeychain := MergeKeychains([]*DockerConfigKeychain{t.keychain, keychain}...)
+	t.mutex.RUnlock()
+
 	ref, err := name.ParseReference(image)
 	if err != nil {
 		return nil, err
 	}
 
-	mergedKeychain := MergeKeychains([]*DockerConfigKeychain{t.keychain, keychain}...)
+	repository := rewrite(ref.Context().Name(), options.Rewrites)
+
+	repo, err := name.NewRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(options.InsecureRegistries, repo.RegistryStr()) {
+		repo, err = name.NewRepository(repository, name.Insecure)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	tags, err := remote.List(ref.Context(), remote.WithAuthFromKeychain(mergedKeychain), remote.WithContext(ctx))
+	ctx, span := tracer.Start(ctx, "list tags", trace.WithAttributes(attribute.String("image.repository", repo.Name())))
+	defer span.End()
+
+	tags, err := remote.List(repo, remote.WithAuthFromKeychain(mergedKeychain), remote.WithContext(ctx), remote.WithTransport(transport))
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
 		return nil, err
 	}
+
+	span.SetAttributes(attribute.Int("image.tags", len(tags)))
+
+	t.tagListsMutex.Lock()
+	t.tagLists[ref.Context().Name()] = TagList{Tags: tags, ListedAt: time.Now()}
+	t.tagListsMutex.Unlock()
+
 	return tags, nil
 }
 
+// LastTags returns the tags of the image's repository from the last successful listing.
+func (t *TagLister) LastTags(image string) (TagList, bool) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return TagList{}, false
+	}
+
+	t.tagListsMutex.RLock()
+	defer t.tagListsMutex.RUnlock()
+
+	tagList, ok := t.tagLists[ref.Context().Name()]
+
+	return tagList, ok
+}
+
+// TagLists returns the last listing of every repository.
+func (t *TagLister) TagLists() map[string]TagList {
+	t.tagListsMutex.RLock()
+	defer t.tagListsMutex.RUnlock()
+
+	tagLists := make(map[string]TagList, len(t.tagLists))
+	for repository, tagList := range t.tagLists {
+		tagLists[repository] = tagList
+	}
+
+	return tagLists
+}
+
+// RestoreTagLists adds previously saved listings, newer listings of the same repository are kept.
+func (t *TagLister) RestoreTagLists(tagLists map[string]TagList) {
+	t.tagListsMutex.Lock()
+	defer t.tagListsMutex.Unlock()
+
+	for repository, tagList := range tagLists {
+		if existing, ok := t.tagLists[repository]; ok && existing.ListedAt.After(tagList.ListedAt) {
+			continue
+		}
+
+		t.tagLists[repository] = tagList
+	}
+}
+
+// GetTagOfImage returns the tag of an image reference, also if it is pinned by a digest as well, like
+// nginx:1.25@sha256:... References pinned by digest only have no tag.
 func (t *TagLister) GetTagOfImage(image string) (string, error) {
 	ref, err := name.ParseReference(image)
 	if err != nil {
 		return "", err
 	}
 
+	if _, ok := ref.(name.Digest); ok {
+		withoutDigest, _, _ := strings.Cut(image, "@")
+		if !strings.Contains(withoutDigest[strings.LastIndex(withoutDigest, "/")+1:], ":") {
+			return "", fmt.Errorf("%w: %s", ErrDigestOnly, image)
+		}
+
+		tag, err := name.NewTag(withoutDigest)
+		if err != nil {
+			return "", err
+		}
+
+		return tag.TagStr(), nil
+	}
+
 	return ref.Identifier(), nil
 }
@@ -0,0 +1,33 @@
+package tags_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/tags"
+)
+
+func TestGetTagOfImage(t *testing.T) {
+	tagLister, err := tags.NewTagLister(&tags.DockerConfigKeychain{})
+	require.NoError(t, err)
+
+	const digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+	for image, expected := range map[string]string{
+		"nginx":                              "latest",
+		"nginx:1.25.3":                       "1.25.3",
+		"registry:5000/team/app:2.0":         "2.0",
+		"nginx:1.25.3@" + digest:             "1.25.3",
+		"registry:5000/team/app:2@" + digest: "2",
+	} {
+		tag, err := tagLister.GetTagOfImage(image)
+		require.NoError(t, err, image)
+		require.Equal(t, expected, tag, image)
+	}
+
+	for _, image := range []string{"nginx@" + digest, "registry:5000/team/app@" + digest} {
+		_, err := tagLister.GetTagOfImage(image)
+		require.ErrorIs(t, err, tags.ErrDigestOnly, image)
+	}
+}
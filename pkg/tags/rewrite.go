@@ -0,0 +1,65 @@
+package tags
+
+import (
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Rewrite replaces a repository prefix before tags are listed, e.g. to query a pull-through mirror instead of the
+// upstream registry. Both prefixes are normalized, so docker.io/library matches nginx.
+type Rewrite struct {
+	From string
+	To   string
+}
+
+// NormalizeRepository expands a registry or repository prefix to the form used by parsed references, e.g. docker.io
+// to index.docker.io. A prefix without a registry host refers to Docker Hub.
+func NormalizeRepository(prefix string) (string, error) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	first, rest, hasRest := strings.Cut(prefix, "/")
+
+	if !strings.ContainsAny(first, ".:") && first != "localhost" {
+		first, rest, hasRest = name.DefaultRegistry, prefix, true
+	}
+
+	registry, err := name.NewRegistry(first)
+	if err != nil {
+		return "", err
+	}
+
+	if !hasRest {
+		return registry.Name(), nil
+	}
+
+	_, err = name.NewRepository(registry.Name() + "/" + rest)
+	if err != nil {
+		return "", err
+	}
+
+	return registry.Name() + "/" + rest, nil
+}
+
+// rewrite applies the first matching rewrite to a normalized repository name. Prefixes only match whole path
+// components.
+func rewrite(repository string, rewrites []Rewrite) string {
+	for _, rewrite := range rewrites {
+		from, err := NormalizeRepository(rewrite.From)
+		if err != nil {
+			continue
+		}
+
+		if repository != from && !strings.HasPrefix(repository, from+"/") {
+			continue
+		}
+
+		to, err := NormalizeRepository(rewrite.To)
+		if err != nil {
+			continue
+		}
+
+		return to + strings.TrimPrefix(repository, from)
+	}
+
+	return repository
+}
@@ -0,0 +1,37 @@
+package tags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeRepository(t *testing.T) {
+	for prefix, expected := range map[string]string{
+		"docker.io":              "index.docker.io",
+		"docker.io/library":      "index.docker.io/library",
+		"library/":               "index.docker.io/library",
+		"ghcr.io/org/app":        "ghcr.io/org/app",
+		"localhost:5000/mirror":  "localhost:5000/mirror",
+		"registry.internal:5000": "registry.internal:5000",
+	} {
+		normalized, err := NormalizeRepository(prefix)
+		require.NoError(t, err, prefix)
+		require.Equal(t, expected, normalized, prefix)
+	}
+
+	_, err := NormalizeRepository("ghcr.io/UPPER")
+	require.Error(t, err)
+}
+
+func TestRewrite(t *testing.T) {
+	rewrites := []Rewrite{
+		{From: "docker.io", To: "mirror.internal/dockerhub"},
+		{From: "ghcr.io/org", To: "registry.internal/ghcr-org"},
+	}
+
+	require.Equal(t, "mirror.internal/dockerhub/library/nginx", rewrite("index.docker.io/library/nginx", rewrites))
+	require.Equal(t, "registry.internal/ghcr-org/app", rewrite("ghcr.io/org/app", rewrites))
+	require.Equal(t, "ghcr.io/organisation/app", rewrite("ghcr.io/organisation/app", rewrites))
+	require.Equal(t, "quay.io/prometheus/node-exporter", rewrite("quay.io/prometheus/node-exporter", rewrites))
+}
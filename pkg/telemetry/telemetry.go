@@ -0,0 +1,155 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+)
+
+const (
+	serviceName = "outdated-image-exporter"
+	scopeName   = "github.com/patrick246/k8s-outdated-image-exporter/pkg/telemetry"
+)
+
+type Config struct {
+	// Endpoint is the base URL of an OTLP/HTTP receiver, e.g. http://otel-collector:4318. Metrics are sent to
+	// /v1/metrics and traces to /v1/traces below it.
+	Endpoint string
+
+	// Headers are sent with every export, e.g. for authentication
+	Headers map[string]string
+
+	// Metrics exports the container metrics in this interval, zero disables them
+	MetricsInterval time.Duration
+
+	// Traces exports a trace of every evaluation
+	Traces bool
+}
+
+// Start exports the container metrics of the evaluator and the traces of evaluations via OTLP. Traces are recorded
+// through the global tracer provider, so they include the registry requests. The returned function flushes pending
+// data and stops the export.
+func Start(ctx context.Context, config Config, evaluator *evaluation.Evaluator) (func(context.Context) error, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	var shutdownFuncs []func(context.Context) error
+
+	shutdown := func(ctx context.Context) error {
+		var errs []error
+
+		for _, shutdownFunc := range shutdownFuncs {
+			errs = append(errs, shutdownFunc(ctx))
+		}
+
+		return errors.Join(errs...)
+	}
+
+	if config.Traces {
+		endpoint, err := url.JoinPath(config.Endpoint, "v1", "traces")
+		if err != nil {
+			return nil, err
+		}
+
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint), otlptracehttp.WithHeaders(config.Headers))
+		if err != nil {
+			return nil, err
+		}
+
+		tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+		shutdownFuncs = append(shutdownFuncs, tracerProvider.Shutdown)
+	}
+
+	if config.MetricsInterval > 0 {
+		endpoint, err := url.JoinPath(config.Endpoint, "v1", "metrics")
+		if err != nil {
+			return nil, errors.Join(err, shutdown(ctx))
+		}
+
+		exporter, err := otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpointURL(endpoint), otlpmetrichttp.WithHeaders(config.Headers))
+		if err != nil {
+			return nil, errors.Join(err, shutdown(ctx))
+		}
+
+		meterProvider := sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(config.MetricsInterval))),
+			sdkmetric.WithResource(res),
+		)
+
+		shutdownFuncs = append(shutdownFuncs, meterProvider.Shutdown)
+
+		err = RegisterGauges(meterProvider.Meter(scopeName), evaluator)
+		if err != nil {
+			return nil, errors.Join(err, shutdown(ctx))
+		}
+	}
+
+	return shutdown, nil
+}
+
+// RegisterGauges observes the container metrics of the evaluator as gauges with the same names and labels as the
+// Prometheus collector.
+func RegisterGauges(meter metric.Meter, evaluator *evaluation.Evaluator) error {
+	gauges := map[string]metric.Float64ObservableGauge{}
+
+	var instruments []metric.Observable
+
+	for name, help := range evaluation.MetricHelp() {
+		gauge, err := meter.Float64ObservableGauge(name, metric.WithDescription(help))
+		if err != nil {
+			return err
+		}
+
+		gauges[name] = gauge
+		instruments = append(instruments, gauge)
+	}
+
+	_, err := meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		for _, series := range evaluator.Series() {
+			gauge, ok := gauges[series.Name]
+			if !ok {
+				continue
+			}
+
+			observer.ObserveFloat64(gauge, series.Value, metric.WithAttributes(attributes(series)...))
+		}
+
+		return nil
+	}, instruments...)
+
+	return err
+}
+
+func attributes(series evaluation.Metric) []attribute.KeyValue {
+	result := make([]attribute.KeyValue, 0, len(series.Labels))
+
+	for name, value := range series.Labels {
+		result = append(result, attribute.String(name, value))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+
+	return result
+}
@@ -0,0 +1,116 @@
+package telemetry_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/telemetry"
+)
+
+// newEvaluator returns an evaluator for a redis container that is one major version behind in a local registry.
+// It isn't run yet.
+func newEvaluator(t *testing.T) *evaluation.Evaluator {
+	host, _ := evaluationtest.Registry(t, "lib/redis:7.2.4", "lib/redis:8.0.0")
+
+	client := make(evaluationtest.Client, 1)
+	client <- clients.ContainerImage{
+		Action: clients.ContainerImageAdded,
+		Name:   "default/web/redis",
+		Image:  host + "/lib/redis:7.2.4",
+		Metadata: map[string]interface{}{
+			clients.MetadataNamespace: "default",
+			clients.MetadataContainer: "redis",
+		},
+	}
+
+	close(client)
+
+	return evaluationtest.NewEvaluator(t, client, evaluation.Config{Workers: 1})
+}
+
+func TestRegisterGauges(t *testing.T) {
+	evaluator := newEvaluator(t)
+	require.NoError(t, evaluator.Run(context.Background()))
+
+	reader := sdkmetric.NewManualReader()
+	require.NoError(t, telemetry.RegisterGauges(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"), evaluator))
+
+	var collected metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &collected))
+	require.Len(t, collected.ScopeMetrics, 1)
+
+	values := map[string]float64{}
+
+	for _, collectedMetric := range collected.ScopeMetrics[0].Metrics {
+		gauge, ok := collectedMetric.Data.(metricdata.Gauge[float64])
+		require.True(t, ok)
+
+		for _, point := range gauge.DataPoints {
+			metricType, _ := point.Attributes.Value(attribute.Key("type"))
+			container, _ := point.Attributes.Value(attribute.Key("container"))
+			require.Equal(t, "redis", container.AsString())
+
+			values[collectedMetric.Name+" "+metricType.AsString()] = point.Value
+		}
+	}
+
+	require.Equal(t, 1.0, values["container_image_outdated major"])
+	require.Equal(t, 0.0, values["container_image_outdated minor"])
+	require.Equal(t, 1.0, values["container_image_check_status "])
+	require.Equal(t, 1.0, values["container_image_info "])
+}
+
+func TestStart(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		received = map[string][]byte{}
+	)
+
+	collector := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, err := io.ReadAll(request.Body)
+		require.NoError(t, err)
+
+		mutex.Lock()
+		received[request.URL.Path] = append(received[request.URL.Path], body...)
+		mutex.Unlock()
+
+		require.Equal(t, "secret", request.Header.Get("X-Token"))
+	}))
+	t.Cleanup(collector.Close)
+
+	evaluator := newEvaluator(t)
+
+	shutdown, err := telemetry.Start(context.Background(), telemetry.Config{
+		Endpoint:        collector.URL,
+		Headers:         map[string]string{"X-Token": "secret"},
+		MetricsInterval: time.Hour,
+		Traces:          true,
+	}, evaluator)
+	require.NoError(t, err)
+
+	require.NoError(t, evaluator.Run(context.Background()))
+	require.NoError(t, shutdown(context.Background()))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	require.Contains(t, string(received["/v1/metrics"]), "container_image_outdated")
+
+	for _, span := range []string{"evaluate", "list tags", "compare versions", "HTTP GET"} {
+		require.True(t, bytes.Contains(received["/v1/traces"], []byte(span)), "span %q not exported", span)
+	}
+}
@@ -0,0 +1,144 @@
+// Package transition follows the severity of containers across the replicas of their workload.
+package transition
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+)
+
+// forgetAfter is how long the severity of a container is remembered after its last member is gone, so rollouts
+// don't count as a new transition
+const forgetAfter = time.Hour
+
+// Tracker groups the results of the replicas of a workload by container. The severity of a container is the highest
+// of its members, so a rollout with old and new replicas side by side doesn't flap.
+type Tracker struct {
+	mutex      sync.Mutex
+	containers map[string]*containerState
+}
+
+type containerState struct {
+	members    map[string]evaluation.Result
+	emptySince time.Time
+
+	// removed is the highest result of the container when its last member was removed
+	removed evaluation.Result
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		containers: map[string]*containerState{},
+	}
+}
+
+// Update records the result of a member. It returns the severity of the container before the update and the result
+// with the highest severity afterwards.
+func (t *Tracker) Update(result evaluation.Result) (evaluation.Severity, evaluation.Result) {
+	key := Key(result)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	state, known := t.containers[key]
+	if !known {
+		state = &containerState{
+			members: map[string]evaluation.Result{},
+		}
+		t.containers[key] = state
+	}
+
+	previous := state.highest().Severity()
+	state.members[result.ContainerImage.Name] = result
+
+	t.prune()
+
+	return previous, state.highest()
+}
+
+// Remove forgets a member. The severity of its container is remembered for a while after the last member is gone.
+func (t *Tracker) Remove(result evaluation.Result) {
+	key := Key(result)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	state, ok := t.containers[key]
+	if !ok {
+		return
+	}
+
+	highest := state.highest()
+
+	delete(state.members, result.ContainerImage.Name)
+
+	if len(state.members) == 0 {
+		state.emptySince = time.Now()
+		state.removed = highest
+	}
+}
+
+// Current returns the result with the highest severity of every container that still has members.
+func (t *Tracker) Current() []evaluation.Result {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	results := make([]evaluation.Result, 0, len(t.containers))
+
+	for _, state := range t.containers {
+		if len(state.members) == 0 {
+			continue
+		}
+
+		results = append(results, state.highest())
+	}
+
+	return results
+}
+
+// prune forgets containers whose members are gone for a while. The mutex must be held.
+func (t *Tracker) prune() {
+	for key, state := range t.containers {
+		if len(state.members) == 0 && time.Since(state.emptySince) > forgetAfter {
+			delete(t.containers, key)
+		}
+	}
+}
+
+// highest returns the member with the highest severity, the first by name of equally severe ones, or the one
+// remembered since the last member was removed.
+func (s *containerState) highest() evaluation.Result {
+	if len(s.members) == 0 {
+		return s.removed
+	}
+
+	var (
+		highest evaluation.Result
+		found   bool
+	)
+
+	for _, result := range s.members {
+		if !found || result.Severity() > highest.Severity() ||
+			result.Severity() == highest.Severity() && result.ContainerImage.Name < highest.ContainerImage.Name {
+			highest, found = result, true
+		}
+	}
+
+	return highest
+}
+
+// Key groups the replicas of a workload by container, other sources use the name of the container.
+func Key(result evaluation.Result) string {
+	workload, ok := result.ContainerImage.Metadata[clients.MetadataWorkload].(k8s.Workload)
+	if !ok {
+		return result.ContainerImage.Name
+	}
+
+	container, _ := result.ContainerImage.Metadata[clients.MetadataContainer].(string)
+
+	return fmt.Sprintf("%s/%s/%s/%s", workload.Namespace, workload.Kind, workload.Name, container)
+}
@@ -0,0 +1,42 @@
+package transition_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/k8s"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation/evaluationtest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/transition"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+func TestTracker(t *testing.T) {
+	web := k8s.Workload{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "prod", Name: "web"}
+	old := evaluationtest.Result(web, "web-1", "nginx", "nginx:1.24.0", version.Evaluation{Minor: 1})
+	updated := evaluationtest.Result(web, "web-2", "nginx", "nginx:1.25.0", version.Evaluation{})
+
+	tracker := transition.NewTracker()
+
+	previous, highest := tracker.Update(old)
+	require.Equal(t, evaluation.SeverityNone, previous)
+	require.Equal(t, old, highest)
+
+	previous, highest = tracker.Update(updated)
+	require.Equal(t, evaluation.SeverityMinor, previous)
+	require.Equal(t, old, highest)
+	require.Equal(t, []evaluation.Result{old}, tracker.Current())
+
+	tracker.Remove(updated)
+	require.Equal(t, []evaluation.Result{old}, tracker.Current())
+
+	// The severity of the last member is remembered for the next one
+	tracker.Remove(old)
+	require.Empty(t, tracker.Current())
+
+	previous, highest = tracker.Update(updated)
+	require.Equal(t, evaluation.SeverityMinor, previous)
+	require.Equal(t, updated, highest)
+	require.Equal(t, "prod/Deployment/web/nginx", transition.Key(updated))
+}
@@ -0,0 +1,159 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+)
+
+var (
+	ErrNotFound = errors.New("image reference not found at its position, it might be templated")
+)
+
+// Edit replaces an image reference at a position in a file.
+type Edit struct {
+	File   string
+	Line   int
+	Column int
+
+	Old string
+	New string
+}
+
+// EditForResult returns the edit upgrading the image of a result to the newest allowed tag. Images expanded from
+// variables are changed where they are written: the default of an ARG making up the whole tag, or a literal tag after
+// variables like ${REGISTRY}/nginx:1.25. Results without a newer tag, without a position in a file, with a tag set
+// by another variable, or pinned by digest have no edit.
+func EditForResult(result evaluation.Result) (Edit, bool) {
+	if result.Err != nil || result.Latest == "" {
+		return Edit{}, false
+	}
+
+	metadata := result.ContainerImage.Metadata
+
+	file, fileOk := metadata[clients.MetadataFile].(string)
+	line, lineOk := metadata[clients.MetadataLine].(int)
+	column, columnOk := metadata[clients.MetadataColumn].(int)
+
+	if !fileOk || !lineOk || !columnOk {
+		return Edit{}, false
+	}
+
+	image := result.ContainerImage.Image
+
+	newImage, ok := ReplaceTag(image, result.Latest)
+	if !ok {
+		return Edit{}, false
+	}
+
+	reference, templated := metadata[clients.MetadataReference].(string)
+
+	switch {
+	case !templated || reference == image:
+		return Edit{File: file, Line: line, Column: column, Old: image, New: newImage}, true
+	case !strings.ContainsAny(reference, ":/$") && strings.HasSuffix(image, ":"+reference):
+		// the reference is the tag itself
+		return Edit{File: file, Line: line, Column: column, Old: reference, New: result.Latest}, true
+	}
+
+	newReference, ok := ReplaceTag(reference, result.Latest)
+	if !ok || strings.Contains(reference[strings.LastIndex(reference, ":"):], "$") {
+		return Edit{}, false
+	}
+
+	return Edit{File: file, Line: line, Column: column, Old: reference, New: newReference}, true
+}
+
+// Templated reports whether the image of a result was expanded from variables.
+func Templated(result evaluation.Result) bool {
+	_, templated := result.ContainerImage.Metadata[clients.MetadataReference].(string)
+
+	return templated
+}
+
+// ReplaceTag swaps the tag of an image reference, keeping the repository exactly as written. References pinned
+// by digest are not changed.
+func ReplaceTag(image, tag string) (string, bool) {
+	if strings.Contains(image, "@") {
+		return "", false
+	}
+
+	separator := strings.LastIndex(image, ":")
+	if separator < 0 || separator < strings.LastIndex(image, "/") {
+		return "", false
+	}
+
+	return image[:separator+1] + tag, true
+}
+
+// Apply performs edits on the content of a file. Only the reference itself is touched, so comments, quoting and
+// formatting are preserved. It returns the new content and the edits that were applied. Edits that can't be
+// applied are returned as error.
+func Apply(content []byte, edits []Edit) ([]byte, []Edit, error) {
+	lines := strings.SplitAfter(string(content), "\n")
+
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+
+	// Apply edits from the back, so an edit doesn't move the column of another one on the same line
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Line != sorted[j].Line {
+			return sorted[i].Line > sorted[j].Line
+		}
+
+		return sorted[i].Column > sorted[j].Column
+	})
+
+	var applied []Edit
+
+	var errs error
+
+	for _, edit := range sorted {
+		if edit.Line < 1 || edit.Line > len(lines) || edit.Column < 1 {
+			errs = errors.Join(errs, fmt.Errorf("%s:%d:%d: %w", edit.File, edit.Line, edit.Column, ErrNotFound))
+
+			continue
+		}
+
+		line := lines[edit.Line-1]
+
+		start := edit.Column - 1
+		if start > len(line) {
+			start = len(line)
+		}
+
+		// The column may point to an opening quote, so look for the reference from there on
+		offset := strings.Index(line[start:], edit.Old)
+		if offset < 0 || offset > 1 {
+			errs = errors.Join(errs, fmt.Errorf("%s:%d:%d: %w", edit.File, edit.Line, edit.Column, ErrNotFound))
+
+			continue
+		}
+
+		offset += start
+		lines[edit.Line-1] = line[:offset] + edit.New + line[offset+len(edit.Old):]
+
+		applied = append(applied, edit)
+	}
+
+	return []byte(strings.Join(lines, "")), applied, errs
+}
+
+// Diff returns a unified diff between the old and new content of a file.
+func Diff(file string, before, after []byte) (string, error) {
+	file = strings.TrimPrefix(strings.TrimPrefix(file, "./"), "/")
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(before)),
+		B:        difflib.SplitLines(string(after)),
+		FromFile: "a/" + file,
+		ToFile:   "b/" + file,
+		Context:  3,
+	})
+}
@@ -0,0 +1,91 @@
+package update_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/update"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+)
+
+func TestReplaceTag(t *testing.T) {
+	image, ok := update.ReplaceTag("registry:5000/nginx:1.25.3", "1.26.0")
+	require.True(t, ok)
+	require.Equal(t, "registry:5000/nginx:1.26.0", image)
+
+	_, ok = update.ReplaceTag("registry:5000/nginx", "1.26.0")
+	require.False(t, ok)
+
+	_, ok = update.ReplaceTag("nginx:1.25.3@sha256:0123", "1.26.0")
+	require.False(t, ok)
+}
+
+func TestApply(t *testing.T) {
+	content := []byte(`containers:
+  # the web server
+  - name: web
+    image: "nginx:1.25.3" # keep me
+  - name: cache
+    image: redis:${REDIS_VERSION}
+`)
+
+	edit, ok := update.EditForResult(evaluation.Result{
+		ContainerImage: clients.ContainerImage{
+			Image: "nginx:1.25.3",
+			Metadata: map[string]interface{}{
+				clients.MetadataFile:   "deploy.yaml",
+				clients.MetadataLine:   4,
+				clients.MetadataColumn: 12,
+			},
+		},
+		Evaluation: version.Evaluation{Latest: "1.26.0"},
+	})
+	require.True(t, ok)
+
+	templated := update.Edit{File: "deploy.yaml", Line: 6, Column: 12, Old: "redis:7.2.4", New: "redis:7.2.5"}
+
+	result, applied, err := update.Apply(content, []update.Edit{edit, templated})
+	require.ErrorIs(t, err, update.ErrNotFound)
+	require.Equal(t, []update.Edit{edit}, applied)
+	require.Equal(t, `containers:
+  # the web server
+  - name: web
+    image: "nginx:1.26.0" # keep me
+  - name: cache
+    image: redis:${REDIS_VERSION}
+`, string(result))
+}
+
+func TestEditForResult_Templated(t *testing.T) {
+	result := func(image, reference string) evaluation.Result {
+		return evaluation.Result{
+			ContainerImage: clients.ContainerImage{
+				Image: image,
+				Metadata: map[string]interface{}{
+					clients.MetadataFile:      "compose.yaml",
+					clients.MetadataLine:      3,
+					clients.MetadataColumn:    12,
+					clients.MetadataReference: reference,
+				},
+			},
+			Evaluation: version.Evaluation{Latest: "1.26.0"},
+		}
+	}
+
+	edit, ok := update.EditForResult(result("registry.internal/nginx:1.25.3", "${REGISTRY}/nginx:1.25.3"))
+	require.True(t, ok)
+	require.Equal(t, "${REGISTRY}/nginx:1.25.3", edit.Old)
+	require.Equal(t, "${REGISTRY}/nginx:1.26.0", edit.New)
+
+	edit, ok = update.EditForResult(result("nginx:1.25.3", "1.25.3"))
+	require.True(t, ok)
+	require.Equal(t, "1.25.3", edit.Old)
+	require.Equal(t, "1.26.0", edit.New)
+
+	_, ok = update.EditForResult(result("nginx:1.25.3-alpine", "nginx:${NGINX_VERSION}-alpine"))
+	require.False(t, ok)
+	require.True(t, update.Templated(result("nginx:1.25.3-alpine", "nginx:${NGINX_VERSION}-alpine")))
+}
@@ -1,11 +1,17 @@
 package version
 
 import (
-	"github.com/hashicorp/go-version"
+	"errors"
+	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/hashicorp/go-version"
 )
 
+// ErrUnparseableTag is returned if the current tag of an image is not a version
+var ErrUnparseableTag = errors.New("current tag is not a version")
+
 type PinMode int
 
 const (
@@ -14,6 +20,74 @@ const (
 	PIN_MINOR
 )
 
+func (p PinMode) String() string {
+	switch p {
+	case PIN_NONE:
+		return "none"
+	case PIN_MAJOR:
+		return "major"
+	case PIN_MINOR:
+		return "minor"
+	}
+
+	return "unknown"
+}
+
+func ParsePinMode(pinMode string) (PinMode, error) {
+	switch pinMode {
+	case "", "none":
+		return PIN_NONE, nil
+	case "major":
+		return PIN_MAJOR, nil
+	case "minor":
+		return PIN_MINOR, nil
+	}
+
+	return PIN_NONE, fmt.Errorf("unknown pin mode %q, expected one of [none, major, minor]", pinMode)
+}
+
+// Reasons for filtering out a tag
+const (
+	ReasonNotSemver   = "not a semantic version"
+	ReasonPrerelease  = "prerelease"
+	ReasonNotNewer    = "not newer than the current version"
+	ReasonPinnedMajor = "different major version, pinned to major"
+	ReasonPinnedMinor = "different minor version, pinned to minor"
+	ReasonConstraint  = "does not satisfy the version constraint"
+	ReasonNotIncluded = "does not match the include filter"
+	ReasonExcluded    = "matches the exclude filter"
+)
+
+type FilteredTag struct {
+	Tag    string
+	Reason string
+}
+
+// Evaluation describes how a tag compares to the available tags.
+type Evaluation struct {
+	Current string
+
+	// Major, Minor and Patch are the differences to the newest allowed version
+	Major int64
+	Minor int64
+	Patch int64
+
+	// Latest is the newest allowed tag, empty if the current one is the newest
+	Latest string
+
+	// LatestMajor, LatestMinor and LatestPatch are the newest allowed tags with a newer major version, a newer
+	// minor version in the current major and a newer patch in the current minor version
+	LatestMajor string
+	LatestMinor string
+	LatestPatch string
+
+	// Candidates are all allowed tags newer than the current one, sorted from oldest to newest
+	Candidates []string
+
+	// Filtered are all tags that were not considered, with the reason why
+	Filtered []FilteredTag
+}
+
 type Checker struct {
 }
 
@@ -22,39 +96,32 @@ func NewChecker() (*Checker, error) {
 }
 
 func (c *Checker) GetDifference(current string, available []string, pinMode PinMode) (major, minor, patch int64, err error) {
-	currentParsed, err := version.NewSemver(current)
+	evaluation, err := c.Evaluate(current, available, Policy{PinMode: pinMode})
 	if err != nil {
 		return
 	}
 
-	versions := make([]*version.Version, 0, len(available))
-	for _, v := range available {
-		// If it doesn't start with a v and doesn't contain a dot, then it's most likely not a semver
-		if !strings.HasPrefix(v, "v") && !strings.Contains(v, ".") {
-			continue
-		}
+	return evaluation.Major, evaluation.Minor, evaluation.Patch, nil
+}
 
-		parsedVersion, err := version.NewSemver(v)
-		// Skip non-semver versions
-		if err != nil {
-			continue
-		}
-		// Skip prereleases
-		if parsedVersion.Prerelease() != "" {
-			continue
-		}
-		// Skip all older version
-		if parsedVersion.LessThanOrEqual(currentParsed) {
-			continue
-		}
+// Evaluate compares the current tag to the available tags, applying the policy to decide which tags are
+// candidates for an update.
+func (c *Checker) Evaluate(current string, available []string, policy Policy) (Evaluation, error) {
+	currentParsed, err := policy.Scheme.parse(current)
+	if err != nil {
+		return Evaluation{}, fmt.Errorf("%w: %v", ErrUnparseableTag, err)
+	}
 
-		// Filter all major versions that are not equal to the current major version
-		if pinMode == PIN_MAJOR && parsedVersion.Segments()[0] != currentParsed.Segments()[0] {
-			continue
-		}
+	evaluation := Evaluation{
+		Current: current,
+	}
+
+	versions := make([]*version.Version, 0, len(available))
+	for _, v := range available {
+		reason, parsedVersion := filter(v, currentParsed, policy)
+		if reason != "" {
+			evaluation.Filtered = append(evaluation.Filtered, FilteredTag{Tag: v, Reason: reason})
 
-		// Filter all minor and major version that don't match the current version
-		if pinMode == PIN_MINOR && (parsedVersion.Segments()[0] != currentParsed.Segments()[0] || parsedVersion.Segments()[1] != currentParsed.Segments()[1]) {
 			continue
 		}
 
@@ -62,28 +129,95 @@ func (c *Checker) GetDifference(current string, available []string, pinMode PinM
 	}
 
 	if len(versions) == 0 {
-		return
+		return evaluation, nil
 	}
 
 	sort.Sort(version.Collection(versions))
 
+	currentSegments := currentParsed.Segments64()
+
+	for _, candidate := range versions {
+		evaluation.Candidates = append(evaluation.Candidates, candidate.Original())
+
+		segments := candidate.Segments64()
+
+		switch {
+		case segments[0] != currentSegments[0]:
+			evaluation.LatestMajor = candidate.Original()
+		case segments[1] != currentSegments[1]:
+			evaluation.LatestMinor = candidate.Original()
+		default:
+			evaluation.LatestPatch = candidate.Original()
+		}
+	}
+
 	latestVersion := versions[len(versions)-1]
+	evaluation.Latest = latestVersion.Original()
+
 	latestSegments := latestVersion.Segments64()
-	currentSegments := currentParsed.Segments64()
 	if latestSegments[0] > currentSegments[0] {
-		major = latestSegments[0] - currentSegments[0]
-		minor = latestSegments[1]
-		patch = latestSegments[1]
-		return
+		evaluation.Major = latestSegments[0] - currentSegments[0]
+		evaluation.Minor = latestSegments[1]
+		evaluation.Patch = latestSegments[1]
+
+		return evaluation, nil
 	}
 	if latestSegments[1] > currentSegments[1] {
-		minor = latestSegments[1] - currentSegments[1]
-		patch = latestSegments[2]
-		return
+		evaluation.Minor = latestSegments[1] - currentSegments[1]
+		evaluation.Patch = latestSegments[2]
+
+		return evaluation, nil
 	}
 	if latestSegments[2] > currentSegments[2] {
-		patch = latestSegments[2] - currentSegments[2]
-		return
+		evaluation.Patch = latestSegments[2] - currentSegments[2]
 	}
-	return
+
+	return evaluation, nil
+}
+
+// filter returns why a tag is not a candidate for an update, or the parsed version if it is.
+func filter(tag string, current *version.Version, policy Policy) (string, *version.Version) {
+	if policy.IncludeTags != nil && !policy.IncludeTags.MatchString(tag) {
+		return ReasonNotIncluded, nil
+	}
+
+	if policy.ExcludeTags != nil && policy.ExcludeTags.MatchString(tag) {
+		return ReasonExcluded, nil
+	}
+
+	// If it doesn't start with a v and doesn't contain a dot, then it's most likely not a semver
+	if policy.Scheme == SchemeSemver && !strings.HasPrefix(tag, "v") && !strings.Contains(tag, ".") {
+		return ReasonNotSemver, nil
+	}
+
+	parsedVersion, err := policy.Scheme.parse(tag)
+	// Skip non-semver versions
+	if err != nil {
+		return ReasonNotSemver, nil
+	}
+	// Skip prereleases. With variants, a suffix shared with the current tag, like the alpine of 1.25-alpine, is a
+	// variant instead.
+	if parsedVersion.Prerelease() != "" && !(policy.Variants && parsedVersion.Prerelease() == current.Prerelease()) {
+		return ReasonPrerelease, nil
+	}
+	// Skip all older version
+	if parsedVersion.LessThanOrEqual(current) {
+		return ReasonNotNewer, nil
+	}
+
+	// Filter all major versions that are not equal to the current major version
+	if policy.PinMode == PIN_MAJOR && parsedVersion.Segments()[0] != current.Segments()[0] {
+		return ReasonPinnedMajor, nil
+	}
+
+	// Filter all minor and major version that don't match the current version
+	if policy.PinMode == PIN_MINOR && (parsedVersion.Segments()[0] != current.Segments()[0] || parsedVersion.Segments()[1] != current.Segments()[1]) {
+		return ReasonPinnedMinor, nil
+	}
+
+	if policy.Constraint != nil && !policy.Constraint.Check(parsedVersion) {
+		return ReasonConstraint, nil
+	}
+
+	return "", parsedVersion
 }
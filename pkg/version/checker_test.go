@@ -75,3 +75,82 @@ func TestChecker_GetDifference(t *testing.T) {
 		})
 	}
 }
+
+func TestChecker_Evaluate(t *testing.T) {
+	versionChecker, err := version.NewChecker()
+	require.NoError(t, err)
+
+	evaluation, err := versionChecker.Evaluate("1.4.2", []string{"latest", "1.4.2", "1.4.5", "1.5.0", "1.6.1", "2.0.0-rc.1", "2.1.0", "1.3.9"}, version.Policy{PinMode: version.PIN_NONE})
+	require.NoError(t, err)
+
+	require.Equal(t, "2.1.0", evaluation.Latest)
+	require.Equal(t, "2.1.0", evaluation.LatestMajor)
+	require.Equal(t, "1.6.1", evaluation.LatestMinor)
+	require.Equal(t, "1.4.5", evaluation.LatestPatch)
+	require.Equal(t, []string{"1.4.5", "1.5.0", "1.6.1", "2.1.0"}, evaluation.Candidates)
+	require.Equal(t, []version.FilteredTag{
+		{Tag: "latest", Reason: version.ReasonNotSemver},
+		{Tag: "1.4.2", Reason: version.ReasonNotNewer},
+		{Tag: "2.0.0-rc.1", Reason: version.ReasonPrerelease},
+		{Tag: "1.3.9", Reason: version.ReasonNotNewer},
+	}, evaluation.Filtered)
+
+	evaluation, err = versionChecker.Evaluate("1.4.2", []string{"1.4.5", "1.5.0", "2.1.0"}, version.Policy{PinMode: version.PIN_MINOR})
+	require.NoError(t, err)
+
+	require.Equal(t, "1.4.5", evaluation.Latest)
+	require.Empty(t, evaluation.LatestMinor)
+	require.Equal(t, []version.FilteredTag{
+		{Tag: "1.5.0", Reason: version.ReasonPinnedMinor},
+		{Tag: "2.1.0", Reason: version.ReasonPinnedMinor},
+	}, evaluation.Filtered)
+}
+
+func TestChecker_EvaluateSchemeAndFilters(t *testing.T) {
+	versionChecker, err := version.NewChecker()
+	require.NoError(t, err)
+
+	evaluation, err := versionChecker.Evaluate("15", []string{"16"}, version.Policy{})
+	require.NoError(t, err)
+	require.Equal(t, []version.FilteredTag{{Tag: "16", Reason: version.ReasonNotSemver}}, evaluation.Filtered)
+
+	evaluation, err = versionChecker.Evaluate("15", []string{"14", "15", "16", "16-alpine", "latest"}, version.Policy{Scheme: version.SchemeLoose})
+	require.NoError(t, err)
+	require.Equal(t, "16", evaluation.Latest)
+	require.Equal(t, int64(1), evaluation.Major)
+
+	excludeTags, err := version.ParseTagFilter(`^2\.`)
+	require.NoError(t, err)
+
+	includeTags, err := version.ParseTagFilter(`-alpine$`)
+	require.NoError(t, err)
+
+	evaluation, err = versionChecker.Evaluate("1.24.0-alpine", []string{"1.25.0", "1.25.0-alpine", "2.0.0-alpine"}, version.Policy{
+		IncludeTags: includeTags,
+		ExcludeTags: excludeTags,
+		Variants:    true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "1.25.0-alpine", evaluation.Latest)
+	require.Equal(t, []version.FilteredTag{
+		{Tag: "1.25.0", Reason: version.ReasonNotIncluded},
+		{Tag: "2.0.0-alpine", Reason: version.ReasonExcluded},
+	}, evaluation.Filtered)
+}
+
+func TestChecker_Variants(t *testing.T) {
+	versionChecker, err := version.NewChecker()
+	require.NoError(t, err)
+
+	available := []string{"1.24.1", "1.25.0-alpine", "1.26.0-rc.1"}
+
+	evaluation, err := versionChecker.Evaluate("1.24.0-alpine", available, version.Policy{})
+	require.NoError(t, err)
+	require.Equal(t, "1.24.1", evaluation.Latest)
+	require.Contains(t, evaluation.Filtered, version.FilteredTag{Tag: "1.25.0-alpine", Reason: version.ReasonPrerelease})
+
+	evaluation, err = versionChecker.Evaluate("1.24.0-alpine", available, version.Policy{Variants: true})
+	require.NoError(t, err)
+	require.Equal(t, "1.25.0-alpine", evaluation.Latest)
+	require.Contains(t, evaluation.Filtered, version.FilteredTag{Tag: "1.26.0-rc.1", Reason: version.ReasonPrerelease})
+}
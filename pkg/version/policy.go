@@ -0,0 +1,140 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	AnnotationPinMode    = "outdated-images.patrick246.de/pin-mode"
+	AnnotationConstraint = "outdated-images.patrick246.de/constraint"
+)
+
+// Policy controls which tags are considered as updates for an image.
+type Policy struct {
+	PinMode PinMode
+
+	// Constraint restricts updates to versions matching it, e.g. "< 2.0" or "~> 1.4". Nil allows every version.
+	Constraint version.Constraints
+
+	Scheme Scheme
+
+	// IncludeTags and ExcludeTags filter tags by name before they are parsed, nil disables the filter
+	IncludeTags *regexp.Regexp
+	ExcludeTags *regexp.Regexp
+
+	// Variants treats a prerelease suffix shared with the current tag, like the alpine of 1.25-alpine, as image
+	// variant. Newer tags with the same suffix are updates, tags with another suffix are still prereleases.
+	Variants bool
+}
+
+// Key identifies the policy's settings, policies with the same key evaluate every image the same way.
+func (p Policy) Key() string {
+	parts := []string{p.PinMode.String(), p.Scheme.String(), "", "", "", strconv.FormatBool(p.Variants)}
+
+	if p.Constraint != nil {
+		parts[2] = p.Constraint.String()
+	}
+
+	if p.IncludeTags != nil {
+		parts[3] = p.IncludeTags.String()
+	}
+
+	if p.ExcludeTags != nil {
+		parts[4] = p.ExcludeTags.String()
+	}
+
+	return fmt.Sprintf("%q", parts)
+}
+
+// Scheme decides which tags are understood as versions.
+type Scheme int
+
+const (
+	// SchemeSemver only accepts tags with at least a dot or a leading v, like 1.25 or v2
+	SchemeSemver Scheme = iota
+
+	// SchemeLoose also accepts single numbers, like the 16 of postgres:16
+	SchemeLoose
+)
+
+func (s Scheme) String() string {
+	switch s {
+	case SchemeSemver:
+		return "semver"
+	case SchemeLoose:
+		return "loose"
+	}
+
+	return "unknown"
+}
+
+func ParseScheme(scheme string) (Scheme, error) {
+	switch scheme {
+	case "", "semver":
+		return SchemeSemver, nil
+	case "loose":
+		return SchemeLoose, nil
+	}
+
+	return SchemeSemver, fmt.Errorf("unknown version scheme %q, expected one of [semver, loose]", scheme)
+}
+
+func (s Scheme) parse(tag string) (*version.Version, error) {
+	if s == SchemeLoose {
+		return version.NewVersion(tag)
+	}
+
+	return version.NewSemver(tag)
+}
+
+// ParseTagFilter compiles a tag filter, an empty filter matches every tag.
+func ParseTagFilter(filter string) (*regexp.Regexp, error) {
+	if filter == "" {
+		return nil, nil
+	}
+
+	compiled, err := regexp.Compile(filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag filter %q: %w", filter, err)
+	}
+
+	return compiled, nil
+}
+
+func ParseConstraint(constraint string) (version.Constraints, error) {
+	if constraint == "" {
+		return nil, nil
+	}
+
+	constraints, err := version.NewConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+
+	return constraints, nil
+}
+
+// PolicyFromAnnotations reads the policy of a container from its annotations, starting from the given defaults.
+// Unknown pin modes fall back to the default, invalid constraints are an error.
+func PolicyFromAnnotations(annotations map[string]string, defaults Policy) (Policy, error) {
+	policy := defaults
+
+	if pinMode, err := ParsePinMode(annotations[AnnotationPinMode]); err == nil && annotations[AnnotationPinMode] != "" {
+		policy.PinMode = pinMode
+	}
+
+	if constraint, ok := annotations[AnnotationConstraint]; ok {
+		constraints, err := ParseConstraint(constraint)
+		if err != nil {
+			return Policy{}, err
+		}
+
+		policy.Constraint = constraints
+	}
+
+	return policy, nil
+}
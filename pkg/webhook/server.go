@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RunServer serves the handler on /validate via TLS. The returned function shuts the server down.
+func RunServer(addr, certFile, keyFile string, handler http.Handler, logger *slog.Logger) (func() error, error) {
+	mux := http.NewServeMux()
+	mux.Handle("/validate", handler)
+	mux.Handle("/healthz", http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(200)
+	}))
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	server := http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		err := server.ServeTLS(lis, certFile, keyFile)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("error serving admission webhook", "addr", addr, "error", err)
+		}
+	}()
+
+	return func() error {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		return server.Shutdown(shutdownCtx)
+	}, nil
+}
@@ -0,0 +1,249 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients/manifest"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/policy"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/report"
+)
+
+// Evaluator checks a single image, see evaluation.Evaluator.
+type Evaluator interface {
+	Evaluate(ctx context.Context, containerImage clients.ContainerImage) (evaluation.Result, error)
+}
+
+type Config struct {
+	// Warn and Deny are the thresholds for returning a warning or rejecting the object, nil disables them
+	Warn *policy.Thresholds
+	Deny *policy.Thresholds
+
+	// Timeout for checking all images of an object. Images not checked in time are admitted with a warning, so a
+	// slow registry doesn't block deployments.
+	Timeout time.Duration
+}
+
+// Handler serves AdmissionReviews for pods and workload kinds. Only images that are new in the object are checked,
+// so updating an unrelated field of a workload doesn't bring up old findings.
+type Handler struct {
+	Config Config
+
+	evaluator Evaluator
+	logger    *slog.Logger
+}
+
+func NewHandler(config Config, evaluator Evaluator, logger *slog.Logger) *Handler {
+	return &Handler{
+		Config:    config,
+		evaluator: evaluator,
+		logger:    logger,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	var review admissionv1.AdmissionReview
+
+	err := json.NewDecoder(r.Body).Decode(&review)
+	if err != nil || review.Request == nil {
+		http.Error(w, "expected an AdmissionReview with a request", http.StatusBadRequest)
+
+		return
+	}
+
+	response := h.review(r.Context(), review.Request)
+	response.UID = review.Request.UID
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err = json.NewEncoder(w).Encode(admissionv1.AdmissionReview{
+		TypeMeta: review.TypeMeta,
+		Response: response,
+	})
+	if err != nil {
+		h.logger.Warn("error writing AdmissionReview response", "error", err)
+	}
+}
+
+func (h *Handler) review(ctx context.Context, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{Allowed: true}
+
+	if request.Operation != admissionv1.Create && request.Operation != admissionv1.Update {
+		return response
+	}
+
+	containerImages, err := manifest.Parse(request.Kind.Kind, request.Object.Raw)
+	if err != nil {
+		h.logger.Warn("error parsing admitted object, admitting it unchecked", "kind", request.Kind.Kind, "name", request.Name, "error", err)
+
+		return response
+	}
+
+	previousImages := map[string]bool{}
+
+	if len(request.OldObject.Raw) > 0 {
+		oldContainerImages, err := manifest.Parse(request.Kind.Kind, request.OldObject.Raw)
+		if err == nil {
+			for _, containerImage := range oldContainerImages {
+				previousImages[containerImage.Image] = true
+			}
+		}
+	}
+
+	var newImages []clients.ContainerImage
+
+	for _, containerImage := range containerImages {
+		if previousImages[containerImage.Image] {
+			continue
+		}
+
+		containerImage.Metadata[clients.MetadataNamespace] = request.Namespace
+		newImages = append(newImages, containerImage)
+	}
+
+	var denied []string
+
+	for _, finding := range h.check(ctx, newImages) {
+		switch {
+		case finding.deny:
+			denied = append(denied, finding.message)
+		case finding.message != "":
+			response.Warnings = append(response.Warnings, finding.message)
+		}
+	}
+
+	if len(denied) > 0 {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonForbidden,
+			Code:    http.StatusForbidden,
+			Message: "outdated images: " + strings.Join(denied, "; "),
+		}
+	}
+
+	return response
+}
+
+type finding struct {
+	message string
+	deny    bool
+}
+
+// check evaluates the images in parallel. Images that can't be checked in time or at all only produce a warning.
+func (h *Handler) check(ctx context.Context, containerImages []clients.ContainerImage) []finding {
+	if h.Config.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, h.Config.Timeout)
+		defer cancel()
+	}
+
+	var (
+		mutex    sync.Mutex
+		findings = make([]finding, len(containerImages))
+		checked  = make([]bool, len(containerImages))
+	)
+
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	for i, containerImage := range containerImages {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			imageFinding := h.checkImage(ctx, containerImage)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			findings[i], checked[i] = imageFinding, true
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return findings
+	case <-ctx.Done():
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// images still being checked keep running in the background, so the findings are copied
+	collected := make([]finding, len(containerImages))
+	unchecked := 0
+
+	for i, containerImage := range containerImages {
+		if checked[i] {
+			collected[i] = findings[i]
+
+			continue
+		}
+
+		collected[i] = finding{message: fmt.Sprintf("%s: %s could not be checked in time", location(containerImage), containerImage.Image)}
+		unchecked++
+	}
+
+	h.logger.Warn("checking images timed out, admitting the unchecked ones", "images", len(containerImages), "unchecked", unchecked)
+
+	return collected
+}
+
+func (h *Handler) checkImage(ctx context.Context, containerImage clients.ContainerImage) finding {
+	field := location(containerImage)
+
+	result, err := h.evaluator.Evaluate(ctx, containerImage)
+	if err != nil {
+		h.logger.Warn("error checking admitted image", "image", containerImage.Image, "error", err)
+
+		return finding{message: fmt.Sprintf("%s: %s could not be checked: %v", field, containerImage.Image, err)}
+	}
+
+	message := fmt.Sprintf("%s: %s, newest allowed tag is %s", field, report.Describe(result), result.Latest)
+
+	switch {
+	case h.Config.Deny.Exceeded(result.Major, result.Minor, result.Patch):
+		return finding{message: message, deny: true}
+	case h.Config.Warn.Exceeded(result.Major, result.Minor, result.Patch):
+		return finding{message: message}
+	}
+
+	return finding{}
+}
+
+// location returns the field of the image inside the object, e.g. spec.template.spec.containers[0].image.
+func location(containerImage clients.ContainerImage) string {
+	path := containerImage.Labels["path"]
+
+	if _, rest, ok := strings.Cut(path, "]."); ok && strings.HasPrefix(path, "[") {
+		return rest
+	}
+
+	return path
+}
@@ -0,0 +1,150 @@
+package webhook_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/clients"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/evaluation"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/policy"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/version"
+	"github.com/patrick246/k8s-outdated-image-exporter/pkg/webhook"
+)
+
+type fakeEvaluator map[string]version.Evaluation
+
+func (f fakeEvaluator) Evaluate(ctx context.Context, containerImage clients.ContainerImage) (evaluation.Result, error) {
+	if containerImage.Metadata[clients.MetadataNamespace] != "prod" {
+		return evaluation.Result{}, errors.New("namespace missing")
+	}
+
+	switch containerImage.Image {
+	case "registry.internal/sidecar:1.0.0":
+		return evaluation.Result{}, errors.New("unauthorized")
+	case "ghcr.io/org/slow:1.0.0":
+		// a registry client that doesn't give up when the request is cancelled
+		time.Sleep(time.Second)
+
+		return evaluation.Result{}, ctx.Err()
+	}
+
+	return evaluation.Result{
+		ContainerImage: containerImage,
+		Evaluation:     f[containerImage.Image],
+	}, nil
+}
+
+var evaluations = fakeEvaluator{
+	"nginx:1.24.0":          {Minor: 1, Latest: "1.25.3"},
+	"postgres:12.0":         {Major: 4, Latest: "16.1"},
+	"ghcr.io/org/app:2.0.0": {},
+}
+
+func review(t *testing.T, server *httptest.Server, file string) *admissionv1.AdmissionResponse {
+	t.Helper()
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	response, err := server.Client().Post(server.URL, "application/json", bytes.NewReader(content))
+	require.NoError(t, err)
+
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var admissionReview admissionv1.AdmissionReview
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&admissionReview))
+	require.Equal(t, "AdmissionReview", admissionReview.Kind)
+
+	return admissionReview.Response
+}
+
+func newServer(t *testing.T, config webhook.Config, evaluator webhook.Evaluator) *httptest.Server {
+	server := httptest.NewTLSServer(webhook.NewHandler(config, evaluator, slog.Default()))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestHandler_Warn(t *testing.T) {
+	server := newServer(t, webhook.Config{Warn: &policy.Thresholds{Minor: 1}}, evaluations)
+
+	response := review(t, server, "testdata/pod-create.json")
+	require.Equal(t, "7c3f0c6e-1d2a-4a53-9b1e-1f2f3a4b5c6d", string(response.UID))
+	require.True(t, response.Allowed)
+	require.Equal(t, []string{
+		"spec.containers[0].image: nginx:1.24.0 is 1 minor version(s) behind, newest allowed tag is 1.25.3",
+		"spec.containers[1].image: registry.internal/sidecar:1.0.0 could not be checked: unauthorized",
+		"spec.initContainers[0].image: postgres:12.0 is 4 major version(s) behind, newest allowed tag is 16.1",
+	}, response.Warnings)
+}
+
+func TestHandler_Deny(t *testing.T) {
+	server := newServer(t, webhook.Config{
+		Warn: &policy.Thresholds{Minor: 1},
+		Deny: &policy.Thresholds{Major: 2},
+	}, evaluations)
+
+	response := review(t, server, "testdata/pod-create.json")
+	require.False(t, response.Allowed)
+	require.Equal(t, int32(http.StatusForbidden), response.Result.Code)
+	require.Equal(t, "outdated images: spec.initContainers[0].image: postgres:12.0 is 4 major version(s) behind, newest allowed tag is 16.1", response.Result.Message)
+	require.Len(t, response.Warnings, 2)
+}
+
+func TestHandler_OnlyNewImages(t *testing.T) {
+	server := newServer(t, webhook.Config{Warn: &policy.Thresholds{Patch: 1}}, evaluations)
+
+	response := review(t, server, "testdata/deployment-update.json")
+	require.True(t, response.Allowed)
+	require.Empty(t, response.Warnings)
+}
+
+func TestHandler_Timeout(t *testing.T) {
+	server := newServer(t, webhook.Config{
+		Deny:    &policy.Thresholds{Major: 2},
+		Timeout: 50 * time.Millisecond,
+	}, evaluations)
+
+	content, err := os.ReadFile("testdata/pod-create.json")
+	require.NoError(t, err)
+
+	content = bytes.ReplaceAll(content, []byte("nginx:1.24.0"), []byte("ghcr.io/org/slow:1.0.0"))
+
+	response, err := server.Client().Post(server.URL, "application/json", bytes.NewReader(content))
+	require.NoError(t, err)
+
+	defer response.Body.Close()
+
+	var admissionReview admissionv1.AdmissionReview
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&admissionReview))
+
+	// The images checked in time are still reported
+	require.False(t, admissionReview.Response.Allowed)
+	require.Equal(t, "outdated images: spec.initContainers[0].image: postgres:12.0 is 4 major version(s) behind, newest allowed tag is 16.1", admissionReview.Response.Result.Message)
+	require.Equal(t, []string{
+		"spec.containers[0].image: ghcr.io/org/slow:1.0.0 could not be checked in time",
+		"spec.containers[1].image: registry.internal/sidecar:1.0.0 could not be checked: unauthorized",
+	}, admissionReview.Response.Warnings)
+}
+
+func TestHandler_BadRequest(t *testing.T) {
+	server := newServer(t, webhook.Config{}, evaluations)
+
+	response, err := server.Client().Post(server.URL, "application/json", bytes.NewReader([]byte(`{"kind":"AdmissionReview"}`)))
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, http.StatusBadRequest, response.StatusCode)
+}